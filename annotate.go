@@ -0,0 +1,93 @@
+package exerr
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+/*
+Annotate attaches fields "kv" (list of alternating field names and values) to the
+error "*errp" when it is not nil. It is meant to be used with defer to add the same
+fields on every return path of the function:
+
+	func load(id int) (err error) {
+		defer exerr.Annotate(&err, "op", "load", "id", id)
+		...
+
+Like [AddField] when the error implements [ErrorWithFields] fields are added to it,
+otherwise the error is wrapped. When the error chain already has stack trace the
+wrapper shares it, otherwise the stack is captured at the function which deferred
+the call (not at the deferred closure when Annotate is called from one).
+*/
+func Annotate(errp *error, kv ...any) {
+	if errp == nil {
+		return
+	}
+	if isNil(*errp) {
+		*errp = nil // normalize typed nil into nil interface
+		return
+	}
+
+	af, ok := (*errp).(ErrorWithFields)
+	if !ok {
+		af = newAnnotation(*errp)
+	}
+	for _, f := range kvFields(kv) {
+		af = af.AddField(f.name, f.value)
+	}
+	*errp = af
+}
+
+/*
+AnnotateMsg wraps the error "*errp" (when it is not nil) prefixing it's message
+with the message created from "format" and "a", ie
+
+	defer exerr.AnnotateMsg(&err, "load %d", id)
+
+is equivalent to
+
+	if err != nil {
+		err = exerr.Errorf("load %d: %w", id, err)
+	}
+
+on every return path of the function. The stack trace is handled like in [Annotate].
+*/
+func AnnotateMsg(errp *error, format string, a ...any) {
+	if errp == nil {
+		return
+	}
+	if isNil(*errp) {
+		*errp = nil // normalize typed nil into nil interface
+		return
+	}
+
+	format += ": %w"
+	a = append(a[:len(a):len(a)], *errp)
+	e := newAnnotation(fmt.Errorf(format, a...))
+	e.setTemplate(format, a)
+	*errp = e
+}
+
+/*
+newAnnotation creates container for the error which is being annotated by deferred
+call of Annotate or AnnotateMsg.
+*/
+func newAnnotation(err error) *exErr {
+	if pcs := stackPC(err); len(pcs) != 0 {
+		return &exErr{err: err, pcs: pcs}
+	}
+
+	// 1=callers; 2=newAnnotation; 3=Annotate*; 4=deferring function or closure
+	pcs := callers(4)
+	if len(pcs) > 1 {
+		frames := runtime.CallersFrames(pcs[:2])
+		first, _ := frames.Next()
+		second, _ := frames.Next()
+		// drop the frame of the deferred closure, unless it was inlined
+		if strings.HasPrefix(first.Function, second.Function+".func") && first.PC != second.PC {
+			pcs = pcs[1:]
+		}
+	}
+	return &exErr{err: err, pcs: pcs}
+}
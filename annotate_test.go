@@ -0,0 +1,130 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func annotateDirect(err error) (rerr error) {
+	defer Annotate(&rerr, "op", "load", "id", 42)
+	return err
+}
+
+func annotateClosure(err error) (rerr error) {
+	defer func() { Annotate(&rerr, "op", "load") }()
+	return err
+}
+
+func annotateMsg(err error) (rerr error) {
+	defer AnnotateMsg(&rerr, "load %d", 42)
+	return err
+}
+
+func Test_Annotate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if err := annotateDirect(nil); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+		if err := annotateClosure(nil); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+		// nil pointer must not panic
+		Annotate(nil, "foo", 1)
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		err := annotateDirect(io.EOF)
+		if !errors.Is(err, io.EOF) {
+			t.Error("expected wrapped error to be detected")
+		}
+		if msg := err.Error(); msg != "EOF" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		expectFieldValue(t, err, "op", "load")
+		expectFieldValue(t, err, "id", 42)
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.annotateDirect" {
+			t.Errorf("expected origin to be the function which deferred Annotate, got %v", f)
+		}
+	})
+
+	t.Run("called from deferred closure", func(t *testing.T) {
+		err := annotateClosure(io.EOF)
+		expectFieldValue(t, err, "op", "load")
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.annotateClosure" {
+			t.Errorf("expected origin to be the function which deferred Annotate, got %v", f)
+		}
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		origErr := New("some error")
+		err := annotateDirect(origErr)
+		if err != origErr {
+			t.Error("expected fields to be added to the original error")
+		}
+		expectFieldValue(t, err, "op", "load")
+		if n := errChainLen(err); n != 1 {
+			t.Errorf("expected chain length 1, got %d", n)
+		}
+	})
+
+	t.Run("stack of the wrapped exerr is shared", func(t *testing.T) {
+		inner := New("some error")
+		err := annotateDirect(fmt.Errorf("wrapped: %w", inner))
+		expectFieldValue(t, err, "id", 42)
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Annotate.func5" {
+			t.Errorf("expected origin to be where the inner error was created, got %v", f)
+		}
+		if s := err.(*exErr).PC(); len(s) == 0 || &s[0] != &inner.(*exErr).PC()[0] {
+			t.Error("expected the stack of the inner error to be used")
+		}
+	})
+}
+
+func Test_AnnotateMsg(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if err := annotateMsg(nil); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+		AnnotateMsg(nil, "foo")
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		err := annotateMsg(io.EOF)
+		if msg := err.Error(); msg != "load 42: EOF" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Error("expected wrapped error to be detected")
+		}
+		if f, a := Template(err); f != "load %d: %w" || len(a) != 1 || a[0] != 42 {
+			t.Errorf("unexpected template %q %v", f, a)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.annotateMsg" {
+			t.Errorf("expected origin to be the function which deferred AnnotateMsg, got %v", f)
+		}
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		origErr := New("some error").AddField("foo", 1)
+		err := annotateMsg(origErr)
+		if msg := err.Error(); msg != "load 42: some error" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if !errors.Is(err, origErr) {
+			t.Error("expected wrapped error to be detected")
+		}
+		expectFieldValue(t, err, "foo", 1)
+		if n := errChainLen(err); n != 2 {
+			t.Errorf("expected chain length 2, got %d", n)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_AnnotateMsg.func3" {
+			t.Errorf("expected origin to be where the inner error was created, got %v", f)
+		}
+	})
+}
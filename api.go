@@ -3,6 +3,8 @@ package exerr
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 )
 
 /*
@@ -15,6 +17,28 @@ type ErrorWithFields interface {
 	AddField(name string, value any) ErrorWithFields
 }
 
+var strictNil atomic.Bool
+
+/*
+SetStrictNil turns on (or off) the strict nil mode. In strict mode [AddField] and [With]
+return nil when the error they are given is nil (by default they return non-nil error
+with message "<nil>").
+
+Note that the nil returned in strict mode is nil interface so chaining AddField calls
+on it causes panic, ie
+
+	exerr.AddField(err, "a", 1).AddField("b", 2)
+
+panics when "err" is nil. In strict mode the result must be checked before chaining
+or [WithFields] should be used instead.
+
+Functions [Wrap], [WithStack], [WithFields], [Annotate] and [AnnotateMsg] always treat
+nil error as "no error" and return nil (or do nothing).
+*/
+func SetStrictNil(strict bool) {
+	strictNil.Store(strict)
+}
+
 /*
 New is like [errors.New] but it returns [ErrorWithFields] which makes it easy to chain AddField
 calls to the error. It also captures the location in the source code where the error was created.
@@ -36,10 +60,14 @@ that information [Stack] func can be used or the error could be checked for impl
 
 which returns return program counters of function invocations on the place the error was created.
 
+The format string and arguments are preserved, see [Template].
+
 Do not use this func to create sentinel errors - for that [errors.New] should be used.
 */
 func Errorf(format string, a ...any) ErrorWithFields {
-	return newExErr(fmt.Errorf(format, a...))
+	e := newExErr(fmt.Errorf(format, a...))
+	e.setTemplate(format, a)
+	return e
 }
 
 /*
@@ -49,12 +77,72 @@ It is like [Errorf] except when the "err" parameter implements ErrorWithFields t
 is added to the "err" instead of creating new wrapper error.
 
 In case nil is sent as "err" parameter AddField returns non nil error with field attached,
-the error message in that case will be "<nil>". It is recommended not to use nil for the
-err parameter.
+the error message in that case will be "<nil>" (unless strict mode is enabled, see
+[SetStrictNil]). It is recommended not to use nil for the err parameter. When the error
+might be nil use [WithFields] instead.
 */
 func AddField(err error, name string, value any) ErrorWithFields {
+	if isNil(err) {
+		if strictNil.Load() {
+			return nil
+		}
+		err = nil
+	}
 	if af, ok := err.(ErrorWithFields); ok {
 		return af.AddField(name, value)
 	}
 	return newExErr(err).AddField(name, value)
 }
+
+/*
+Wrap returns error which wraps "err" and prefixes it's message with "msg", ie the
+message of the error is "msg: err.Error()". Returns nil when "err" is nil.
+
+Like [Errorf] Wrap captures the location in the source code where it was called.
+*/
+func Wrap(err error, msg string) ErrorWithFields {
+	if isNil(err) {
+		return nil
+	}
+	e := newExErr(fmt.Errorf("%s: %w", msg, err))
+	e.setTemplate(strings.ReplaceAll(msg, "%", "%%")+": %w", nil)
+	return e
+}
+
+/*
+WithStack makes sure that "err" has stack trace. When there already is error with
+stack trace in the chain of "err" no new stack is captured, the returned error shares
+the stack of the existing one (or is "err" itself when it implements ErrorWithFields).
+Returns nil when "err" is nil.
+*/
+func WithStack(err error) ErrorWithFields {
+	if isNil(err) {
+		return nil
+	}
+	if pcs := stackPC(err); len(pcs) != 0 {
+		if af, ok := err.(ErrorWithFields); ok {
+			return af
+		}
+		return &exErr{err: err, pcs: pcs}
+	}
+	return newExErr(err)
+}
+
+/*
+WithFields attaches fields "kv" (list of alternating field names and values) to
+the error. It is like calling [AddField] for every field except that when "err" is
+nil WithFields returns nil.
+*/
+func WithFields(err error, kv ...any) ErrorWithFields {
+	if isNil(err) {
+		return nil
+	}
+	af, ok := err.(ErrorWithFields)
+	if !ok {
+		af = newExErr(err)
+	}
+	for _, f := range kvFields(kv) {
+		af = af.AddField(f.name, f.value)
+	}
+	return af
+}
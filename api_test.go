@@ -3,6 +3,7 @@ package exerr
 import (
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 )
 
@@ -257,6 +258,261 @@ func Test_AddField(t *testing.T) {
 	})
 }
 
+func Test_Wrap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error as input", func(t *testing.T) {
+		if err := Wrap(nil, "msg"); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		var err error = Wrap(nil, "msg")
+		if err != nil {
+			t.Errorf("expected nil error interface, got %#v", err)
+		}
+	})
+
+	t.Run("stdlib error as input", func(t *testing.T) {
+		origErr := fmt.Errorf("some error")
+		err := Wrap(origErr, "reading config").AddField("fieldA", 1)
+		if msg := err.Error(); msg != "reading config: some error" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if !errors.Is(err, origErr) {
+			t.Error("errors.Is doesn't detect the wrapped error")
+		}
+		expectFieldValue(t, err, "fieldA", 1)
+		if n := errChainLen(err); n != 2 {
+			t.Errorf("expected chain length 2, got %d", n)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Wrap.func2" {
+			t.Errorf("unexpected origin %v", f)
+		}
+	})
+
+	t.Run("message with percent sign", func(t *testing.T) {
+		err := Wrap(fmt.Errorf("some error"), "100% %d")
+		if msg := err.Error(); msg != "100% %d: some error" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if f, _ := Template(err); f != "100%% %%d: %w" {
+			t.Errorf("unexpected template %q", f)
+		}
+	})
+}
+
+func Test_WithStack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error as input", func(t *testing.T) {
+		var err error = WithStack(nil)
+		if err != nil {
+			t.Errorf("expected nil error interface, got %#v", err)
+		}
+	})
+
+	t.Run("stdlib error as input", func(t *testing.T) {
+		origErr := fmt.Errorf("some error")
+		err := WithStack(origErr)
+		if err.Error() != origErr.Error() {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+		if !errors.Is(err, origErr) {
+			t.Error("errors.Is doesn't detect the wrapped error")
+		}
+		if n := errChainLen(err); n != 1 {
+			t.Errorf("expected chain length 1, got %d", n)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_WithStack.func2" {
+			t.Errorf("unexpected origin %v", f)
+		}
+	})
+
+	t.Run("exerr as input", func(t *testing.T) {
+		origErr := New("some error")
+		if err := WithStack(origErr); err != origErr {
+			t.Error("expected the input error to be returned")
+		}
+	})
+
+	t.Run("stack exists in the chain", func(t *testing.T) {
+		inner := New("some error")
+		origErr := fmt.Errorf("wrapped: %w", inner)
+		err := WithStack(origErr)
+		if !errors.Is(err, origErr) {
+			t.Error("errors.Is doesn't detect the wrapped error")
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_WithStack.func4" {
+			t.Errorf("unexpected origin %v", f)
+		}
+		if s := err.(*exErr).PC(); len(s) == 0 || &s[0] != &inner.(*exErr).PC()[0] {
+			t.Error("expected the stack of the inner error to be used")
+		}
+	})
+}
+
+func Test_WithFields(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error as input", func(t *testing.T) {
+		var err error = WithFields(nil, "fieldA", 1)
+		if err != nil {
+			t.Errorf("expected nil error interface, got %#v", err)
+		}
+	})
+
+	t.Run("stdlib error as input", func(t *testing.T) {
+		origErr := fmt.Errorf("some error")
+		err := WithFields(origErr, "fieldA", 1, "fieldB", "foo")
+
+		flds := Fields(err)
+		if n := len(flds); n != 2 {
+			t.Errorf("expected that error has %d fields attached, got %d", 2, n)
+		}
+		containsField(t, flds, "fieldA", 1)
+		containsField(t, flds, "fieldB", "foo")
+
+		if !errors.Is(err, origErr) {
+			t.Error("errors.Is doesn't detect the wrapped error")
+		}
+		if n := errChainLen(err); n != 1 {
+			t.Errorf("expected chain length 1, got %d", n)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_WithFields.func2" {
+			t.Errorf("unexpected origin %v", f)
+		}
+	})
+
+	t.Run("exerr as input", func(t *testing.T) {
+		origErr := New("some error").AddField("fieldA", 1)
+		err := WithFields(origErr, "fieldB", 2)
+		if err != origErr {
+			t.Error("expected fields to be added to the input error")
+		}
+		expectFieldValue(t, err, "fieldA", 1)
+		expectFieldValue(t, err, "fieldB", 2)
+	})
+}
+
+func Test_SetStrictNil(t *testing.T) {
+	// not parallel as strict mode is global setting
+	t.Cleanup(func() { SetStrictNil(false) })
+
+	key := NewKey[int]("fieldA")
+
+	// default mode, nil error becomes "<nil>" error
+	if err := AddField(nil, "fieldA", 1); err == nil || err.Error() != "<nil>" {
+		t.Errorf(`expected "<nil>" error, got %v`, err)
+	}
+	if err := With(nil, key, 1); err == nil || err.Error() != "<nil>" {
+		t.Errorf(`expected "<nil>" error, got %v`, err)
+	}
+
+	SetStrictNil(true)
+	var err error = AddField(nil, "fieldA", 1)
+	if err != nil {
+		t.Errorf("expected nil error interface in strict mode, got %#v", err)
+	}
+	err = With(nil, key, 1)
+	if err != nil {
+		t.Errorf("expected nil error interface in strict mode, got %#v", err)
+	}
+	// typed nil is treated as nil
+	err = AddField((*exErr)(nil), "fieldA", 1)
+	if err != nil {
+		t.Errorf("expected nil error interface in strict mode, got %#v", err)
+	}
+	// non-nil errors are not affected
+	if err := AddField(fmt.Errorf("some error"), "fieldA", 1); err == nil {
+		t.Error("expected non-nil error")
+	}
+
+	SetStrictNil(false)
+	if err := AddField(nil, "fieldA", 1); err == nil {
+		t.Error("expected non-nil error after strict mode has been turned off")
+	}
+}
+
+func Test_nil_pitfalls(t *testing.T) {
+	t.Parallel()
+
+	// function returning ErrorWithFields, result assigned to error
+	find := func(fail bool) error {
+		var err error
+		if fail {
+			err = fmt.Errorf("not found")
+		}
+		return WithFields(err, "id", 1)
+	}
+
+	t.Run("nil ErrorWithFields converted to error", func(t *testing.T) {
+		if err := find(false); err != nil {
+			t.Errorf("expected nil error, got %#v", err)
+		}
+		if err := find(true); err == nil {
+			t.Error("expected non-nil error")
+		}
+	})
+
+	t.Run("typed nil is treated as nil", func(t *testing.T) {
+		var typedNil error = (*exErr)(nil)
+		if typedNil == nil {
+			t.Fatal("test setup: typed nil should not be equal to nil")
+		}
+
+		var err error = WithFields(typedNil, "a", 1)
+		if err != nil {
+			t.Errorf("WithFields: expected nil error, got %#v", err)
+		}
+		if err = Wrap(typedNil, "msg"); err != nil {
+			t.Errorf("Wrap: expected nil error, got %#v", err)
+		}
+		if err = WithStack(typedNil); err != nil {
+			t.Errorf("WithStack: expected nil error, got %#v", err)
+		}
+		if err = Scope("a", 1).Wrap(typedNil); err != nil {
+			t.Errorf("Builder.Wrap: expected nil error, got %#v", err)
+		}
+
+		err = typedNil
+		Annotate(&err, "a", 1)
+		if err != nil {
+			t.Errorf("Annotate: expected typed nil to be replaced with nil, got %#v", err)
+		}
+		err = typedNil
+		AnnotateMsg(&err, "msg")
+		if err != nil {
+			t.Errorf("AnnotateMsg: expected typed nil to be replaced with nil, got %#v", err)
+		}
+
+		// in non-strict mode AddField creates "<nil>" error instead of panicking
+		if err = AddField(typedNil, "a", 1); err == nil || err.Error() != "<nil>" {
+			t.Errorf(`AddField: expected "<nil>" error, got %v`, err)
+		}
+	})
+
+	t.Run("query funcs and typed nil", func(t *testing.T) {
+		var err error = (*exErr)(nil)
+		if msg := err.Error(); msg != "<nil>" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if f := Fields(err); len(f) != 0 {
+			t.Errorf("expected no fields, got %v", f)
+		}
+		if _, ok := FieldValue(err, "a"); ok {
+			t.Error("unexpectedly field was found")
+		}
+		if s := Stack(err); s != nil {
+			t.Errorf("expected no stack, got %v", s)
+		}
+		if f, _ := Template(err); f != "" {
+			t.Errorf("expected no template, got %q", f)
+		}
+		if errors.Is(err, io.EOF) {
+			t.Error("unexpectedly errors.Is returned true")
+		}
+	})
+}
+
 func expectFieldValue(t *testing.T, err error, name string, value any) {
 	t.Helper()
 
@@ -0,0 +1,144 @@
+package exerr
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+BuildInfo describes the build of the binary which produced the error.
+*/
+type BuildInfo struct {
+	Path      string `json:"path,omitempty"`    // main module path
+	Version   string `json:"version,omitempty"` // main module version
+	Revision  string `json:"vcs_revision,omitempty"`
+	Modified  bool   `json:"vcs_modified,omitempty"` // working tree had uncommitted changes
+	GoVersion string `json:"go_version,omitempty"`
+
+	deps map[string]string // dependency module path -> version
+}
+
+var (
+	buildInfo     *BuildInfo
+	buildInfoOnce sync.Once
+)
+
+/*
+ReadBuildInfo returns build information of the running binary (read using [debug.ReadBuildInfo]).
+Returns nil when the binary has been built without module support.
+
+Should be considered to be read-only, ie do not modify!
+*/
+func ReadBuildInfo() *BuildInfo {
+	buildInfoOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			buildInfo = newBuildInfo(bi)
+		}
+	})
+	return buildInfo
+}
+
+func newBuildInfo(bi *debug.BuildInfo) *BuildInfo {
+	r := &BuildInfo{
+		Path:      bi.Main.Path,
+		Version:   bi.Main.Version,
+		GoVersion: bi.GoVersion,
+		deps:      make(map[string]string, len(bi.Deps)),
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			r.Revision = s.Value
+		case "vcs.modified":
+			r.Modified = s.Value == "true"
+		}
+	}
+	for _, m := range bi.Deps {
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		r.deps[m.Path] = m.Version
+	}
+	return r
+}
+
+/*
+moduleOf returns the path and revision of the module which contains package "pkg".
+For the main module the revision is VCS revision, for dependencies it is the commit
+hash in case of pseudo-version, module version otherwise.
+*/
+func (bi *BuildInfo) moduleOf(pkg string) (path, rev string, ok bool) {
+	if bi.Path != "" && hasPathPrefix(pkg, bi.Path) {
+		path, rev = bi.Path, bi.Revision
+	}
+	for mod, ver := range bi.deps {
+		if len(mod) > len(path) && hasPathPrefix(pkg, mod) {
+			path, rev = mod, ver
+			if i := strings.LastIndexByte(ver, '-'); i > 0 && len(ver)-i == 13 {
+				rev = ver[i+1:]
+			}
+		}
+	}
+	return path, rev, path != "" && rev != ""
+}
+
+/*
+SourceLinks maps module path prefix to URL template for creating links to the source
+code of the stack frames. Template may contain following placeholders:
+
+  - {module} - path of the module the frame belongs to;
+  - {rev} - VCS revision of the main module or version of the dependency module;
+  - {path} - path of the source file relative to the module root;
+  - {line} - line number;
+
+For example to link to the sources of the modules hosted in GitHub
+
+	exerr.SourceLinks{"github.com/": "https://{module}/blob/{rev}/{path}#L{line}"}
+
+could be used. When multiple prefixes match the longest one is used.
+
+Frames of the main package (ie cmd/server/main.go) get link only when the binary has
+been built with -trimpath flag as otherwise the location of the package in the module
+can't be determined.
+*/
+type SourceLinks map[string]string
+
+/*
+link returns URL of the source code of the frame "f", empty string when the module
+of the frame doesn't have template or it's revision is not known.
+*/
+func (sl SourceLinks) link(bi *BuildInfo, f Frame) string {
+	if bi == nil {
+		return ""
+	}
+	pkg := f.Package()
+	if pkg == "main" {
+		pkg = mainPackage(f.File, bi.Path)
+	}
+	prefix := ""
+	tmpl, ok := "", false
+	for k, v := range sl {
+		if strings.HasPrefix(pkg, k) && len(k) >= len(prefix) {
+			prefix, tmpl, ok = k, v, true
+		}
+	}
+	if !ok {
+		return ""
+	}
+	mod, rev, ok := bi.moduleOf(pkg)
+	if !ok {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{module}", mod,
+		"{rev}", rev,
+		"{path}", f.relFile(mod),
+		"{line}", strconv.Itoa(f.Line),
+	).Replace(tmpl)
+}
+
+func hasPathPrefix(s, prefix string) bool {
+	return s == prefix || (strings.HasPrefix(s, prefix) && s[len(prefix)] == '/')
+}
@@ -0,0 +1,138 @@
+package exerr
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func Test_ReadBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	bi := ReadBuildInfo()
+	if bi == nil {
+		t.Fatal("expected build info to be available")
+	}
+	if bi.GoVersion == "" {
+		t.Error("expected Go version to be assigned")
+	}
+	if bi != ReadBuildInfo() {
+		t.Error("expected build info to be cached")
+	}
+}
+
+func Test_newBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	bi := newBuildInfo(&debug.BuildInfo{
+		GoVersion: "go1.21.0",
+		Main:      debug.Module{Path: "github.com/org/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/org/lib", Version: "v1.2.3"},
+			{Path: "github.com/org/old", Version: "v0.1.0", Replace: &debug.Module{Path: "github.com/org/new", Version: "v0.2.0"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	exp := BuildInfo{Path: "github.com/org/app", Version: "(devel)", Revision: "0123456789abcdef", Modified: true, GoVersion: "go1.21.0"}
+	if bi.Path != exp.Path || bi.Version != exp.Version || bi.Revision != exp.Revision || bi.Modified != exp.Modified || bi.GoVersion != exp.GoVersion {
+		t.Errorf("expected\n%#v\ngot\n%#v", exp, *bi)
+	}
+	if v := bi.deps["github.com/org/lib"]; v != "v1.2.3" {
+		t.Errorf("unexpected dependency version %q", v)
+	}
+	if v := bi.deps["github.com/org/new"]; v != "v0.2.0" {
+		t.Errorf("expected replacement module to be used, got %v", bi.deps)
+	}
+}
+
+func Test_SourceLinks_link(t *testing.T) {
+	t.Parallel()
+
+	bi := &BuildInfo{
+		Path:     "github.com/org/app",
+		Revision: "abc123",
+		deps: map[string]string{
+			"github.com/org/lib":         "v1.2.3",
+			"github.com/org/lib/sub":     "v0.0.0-20230102150405-0123456789ab",
+			"gitlab.example.com/group/x": "v1.0.0",
+		},
+	}
+	links := SourceLinks{
+		"github.com/":         "https://{module}/blob/{rev}/{path}#L{line}",
+		"github.com/org/lib/": "https://src.example.com/lib?rev={rev}&file={path}&line={line}",
+	}
+
+	testCases := []struct {
+		frame Frame
+		link  string
+	}{
+		{
+			frame: Frame{Function: "github.com/org/app.main", File: "/build/main.go", Line: 10},
+			link:  "https://github.com/org/app/blob/abc123/main.go#L10",
+		},
+		{
+			frame: Frame{Function: "github.com/org/app/internal/db.(*DB).Query", File: "/build/internal/db/db.go", Line: 42},
+			link:  "https://github.com/org/app/blob/abc123/internal/db/db.go#L42",
+		},
+		{
+			frame: Frame{Function: "github.com/org/lib.Func", File: "/go/pkg/mod/github.com/org/lib@v1.2.3/lib.go", Line: 7},
+			link:  "https://github.com/org/lib/blob/v1.2.3/lib.go#L7",
+		},
+		{
+			frame: Frame{Function: "github.com/org/lib/sub/pkg.Func", File: "/go/pkg/mod/github.com/org/lib/sub@v0.0.0/pkg/a.go", Line: 1},
+			link:  "https://src.example.com/lib?rev=0123456789ab&file=pkg/a.go&line=1",
+		},
+		{
+			// no template for the module
+			frame: Frame{Function: "gitlab.example.com/group/x.F", File: "x.go", Line: 1},
+			link:  "",
+		},
+		{
+			// module is not known
+			frame: Frame{Function: "github.com/other/x.F", File: "x.go", Line: 1},
+			link:  "",
+		},
+		{
+			// main package, built with -trimpath
+			frame: Frame{Function: "main.run", File: "github.com/org/app/cmd/server/main.go", Line: 12},
+			link:  "https://github.com/org/app/blob/abc123/cmd/server/main.go#L12",
+		},
+		{
+			// main package in the module root, built with -trimpath
+			frame: Frame{Function: "main.main", File: "github.com/org/app/main.go", Line: 5},
+			link:  "https://github.com/org/app/blob/abc123/main.go#L5",
+		},
+		{
+			// main package, location in the module is not known without -trimpath
+			frame: Frame{Function: "main.main", File: "/build/cmd/server/main.go", Line: 12},
+			link:  "",
+		},
+		{
+			frame: Frame{Function: "runtime.goexit", File: "/usr/local/go/src/runtime/asm_amd64.s", Line: 1700},
+			link:  "",
+		},
+	}
+
+	for _, tc := range testCases {
+		if link := links.link(bi, tc.frame); link != tc.link {
+			t.Errorf("expected link of %s to be\n%q\ngot\n%q", tc.frame.Function, tc.link, link)
+		}
+	}
+
+	t.Run("revision of the main module is not known", func(t *testing.T) {
+		bi := &BuildInfo{Path: "github.com/org/app"}
+		if link := links.link(bi, Frame{Function: "github.com/org/app.main", File: "main.go", Line: 1}); link != "" {
+			t.Errorf("expected no link, got %q", link)
+		}
+	})
+
+	t.Run("no build info", func(t *testing.T) {
+		if link := links.link(nil, Frame{Function: "github.com/org/app.main", File: "main.go", Line: 1}); link != "" {
+			t.Errorf("expected no link, got %q", link)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package exerr
+
+import "context"
+
+// class is the explicit classification of the error, see MarkRetryable and MarkPermanent.
+type class uint8
+
+const (
+	classNone class = iota
+	classRetryable
+	classPermanent
+)
+
+/*
+MarkRetryable marks the error "err" as retryable, ie the operation which failed may
+succeed when tried again (see [IsRetryable]). The mark is stored on new exerr error
+wrapping "err" (no new stack trace is captured), "err" itself is not modified so the
+returned error must be used instead of "err":
+
+	return exerr.MarkRetryable(err)
+
+The mark survives wrapping by other errors. Returns nil when "err" is nil.
+*/
+func MarkRetryable(err error) error {
+	return markClass(err, classRetryable)
+}
+
+/*
+MarkPermanent marks the error "err" as permanent, ie retrying the operation which
+failed is pointless. It takes precedence over the retryable mark or detection of
+timeout further down in the chain, see [IsRetryable]. Like [MarkRetryable] it returns
+new error wrapping "err". Returns nil when "err" is nil.
+*/
+func MarkPermanent(err error) error {
+	return markClass(err, classPermanent)
+}
+
+/*
+markClass returns new container for the class rather than storing it on existing
+error as the error might be shared (ie returned by the callee to multiple callers)
+and the position of the mark in the chain matters for the precedence.
+*/
+func markClass(err error, c class) error {
+	if isNil(err) {
+		return nil
+	}
+	return &exErr{err: err, pcs: stackPC(err), class: c}
+}
+
+/*
+IsRetryable reports whether the operation which failed with "err" may succeed when
+tried again. The errors in the chain are examined in the [Walk] order and
+  - when explicit mark ([MarkRetryable] or [MarkPermanent]) is found the outermost
+    one decides, ie the code up in the call chain can override the classification
+    of the code it calls;
+  - otherwise the error is retryable when any error in the chain is timeout or
+    temporary error (see [IsTimeout] and [IsTemporary]).
+
+Note that context.Canceled is not retryable unless explicitly marked so.
+*/
+func IsRetryable(err error) bool {
+	switch classOf(err) {
+	case classRetryable:
+		return true
+	case classPermanent:
+		return false
+	}
+	return IsTimeout(err) || IsTemporary(err)
+}
+
+/*
+IsPermanent returns true when the outermost explicit mark in the chain of "err" is
+set by [MarkPermanent]. Errors without explicit mark are not permanent, nor are they
+necessarily retryable.
+*/
+func IsPermanent(err error) bool {
+	return classOf(err) == classPermanent
+}
+
+/*
+IsTimeout returns true when any error in the chain of "err" is [context.DeadlineExceeded]
+or implements
+
+	Timeout() bool
+
+method which returns true (ie net.Error, os.ErrDeadlineExceeded).
+*/
+func IsTimeout(err error) bool {
+	return anyLayer(err, func(e error) bool {
+		if e == context.DeadlineExceeded {
+			return true
+		}
+		t, ok := e.(interface{ Timeout() bool })
+		return ok && t.Timeout()
+	})
+}
+
+/*
+IsTemporary returns true when any error in the chain of "err" implements
+
+	Temporary() bool
+
+method which returns true.
+*/
+func IsTemporary(err error) bool {
+	return anyLayer(err, func(e error) bool {
+		t, ok := e.(interface{ Temporary() bool })
+		return ok && t.Temporary()
+	})
+}
+
+// classOf returns the outermost explicit class in the chain of "err".
+func classOf(err error) (c class) {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if ee, ok := e.(*exErr); ok && ee != nil {
+			c = ee.class
+		}
+		return c == classNone
+	})
+	return c
+}
+
+/*
+anyLayer returns true when "fn" returns true for any error in the chain of "err".
+As exErr is a container "fn" is called with the error it wraps instead.
+*/
+func anyLayer(err error, fn func(e error) bool) (found bool) {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if ee, ok := e.(*exErr); ok {
+			if ee == nil {
+				return true
+			}
+			e = ee.err
+		}
+		found = e != nil && fn(e)
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,190 @@
+package exerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+)
+
+type tempError struct{ temporary bool }
+
+func (e tempError) Error() string   { return "temp error" }
+func (e tempError) Temporary() bool { return e.temporary }
+
+func Test_MarkRetryable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if err := MarkRetryable(nil); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		if err := MarkPermanent((*exErr)(nil)); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		if IsRetryable(nil) || IsPermanent(nil) {
+			t.Error("nil error must not be classified")
+		}
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		err := New("failed").AddField("id", 1)
+		if IsRetryable(err) || IsPermanent(err) {
+			t.Error("new error must not be classified")
+		}
+		retryable := MarkRetryable(err)
+		if !IsRetryable(retryable) || IsPermanent(retryable) {
+			t.Error("expected error to be retryable")
+		}
+		permanent := MarkPermanent(err)
+		if IsRetryable(permanent) || !IsPermanent(permanent) {
+			t.Error("expected error to be permanent")
+		}
+		if IsRetryable(err) || IsPermanent(err) {
+			t.Error("original error must not be modified")
+		}
+		if !IsRetryable(retryable) {
+			t.Error("marking other error must not change the classification")
+		}
+
+		// marked error has the same message, fields and stack as the original
+		if permanent.Error() != err.Error() || !errors.Is(permanent, err) {
+			t.Errorf("unexpected marked error %v", permanent)
+		}
+		if v, ok := FieldValue(permanent, "id"); !ok || v != 1 {
+			t.Errorf("expected field of the original error, got %v", v)
+		}
+		if f, o := Frames(permanent), Frames(err); len(f) == 0 || f[0] != o[0] {
+			t.Errorf("expected stack of the original error, got %v", f)
+		}
+		var ee *exErr
+		if !errors.As(permanent, &ee) {
+			t.Error("expected errors.As to find exErr")
+		}
+	})
+
+	t.Run("concurrent marking", func(t *testing.T) {
+		err := New("shared")
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mark := MarkRetryable
+				if i%2 == 0 {
+					mark = MarkPermanent
+				}
+				if e := mark(err); IsPermanent(e) != (i%2 == 0) {
+					t.Errorf("unexpected classification of %d", i)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("stdlib error is wrapped", func(t *testing.T) {
+		err := MarkRetryable(io.ErrUnexpectedEOF)
+		if !IsRetryable(err) {
+			t.Error("expected error to be retryable")
+		}
+		if IsRetryable(io.ErrUnexpectedEOF) {
+			t.Error("original error must not be marked")
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Error("expected errors.Is to detect the marked error")
+		}
+		if err.Error() != io.ErrUnexpectedEOF.Error() {
+			t.Errorf("unexpected message %q", err)
+		}
+	})
+
+	t.Run("survives wrapping", func(t *testing.T) {
+		err := MarkPermanent(New("failed"))
+		for _, e := range []error{
+			fmt.Errorf("wrapped: %w", err),
+			Wrap(err, "wrapped"),
+			errors.Join(io.EOF, fmt.Errorf("wrapped: %w", err)),
+		} {
+			if !IsPermanent(e) {
+				t.Errorf("expected %q to be permanent", e)
+			}
+		}
+	})
+}
+
+func Test_IsRetryable(t *testing.T) {
+	t.Parallel()
+
+	timeout := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	testCases := []struct {
+		name      string
+		err       error
+		retryable bool
+		permanent bool
+	}{
+		{name: "stdlib error", err: io.EOF},
+		{name: "canceled context", err: fmt.Errorf("query: %w", context.Canceled)},
+		{name: "deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), retryable: true},
+		{name: "net timeout", err: Wrap(timeout, "dial"), retryable: true},
+		{name: "temporary error", err: Errorf("send: %w", tempError{temporary: true}), retryable: true},
+		{name: "not temporary error", err: Errorf("send: %w", tempError{temporary: false})},
+		{name: "timeout marked permanent", err: MarkPermanent(Wrap(timeout, "dial")), permanent: true},
+		{name: "outer mark wins: retryable", err: MarkRetryable(Wrap(MarkPermanent(New("inner")), "outer")), retryable: true},
+		{name: "outer mark wins: permanent", err: MarkPermanent(Wrap(MarkRetryable(New("inner")), "outer")), permanent: true},
+		{name: "mark wrapped by stdlib", err: fmt.Errorf("std: %w", MarkPermanent(Wrap(MarkRetryable(io.EOF), "outer"))), permanent: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if r := IsRetryable(tc.err); r != tc.retryable {
+				t.Errorf("expected IsRetryable to return %t, got %t", tc.retryable, r)
+			}
+			if r := IsPermanent(tc.err); r != tc.permanent {
+				t.Errorf("expected IsPermanent to return %t, got %t", tc.permanent, r)
+			}
+		})
+	}
+}
+
+func Test_IsTimeout(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err     error
+		timeout bool
+	}{
+		{err: nil},
+		{err: io.EOF},
+		{err: context.Canceled},
+		{err: context.DeadlineExceeded, timeout: true},
+		{err: Errorf("read: %w", os.ErrDeadlineExceeded), timeout: true},
+		{err: errors.Join(io.EOF, Wrap(context.DeadlineExceeded, "query")), timeout: true},
+		{err: &net.DNSError{Err: "no such host", IsTimeout: false}},
+		{err: WithStack(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), timeout: true},
+	}
+
+	for _, tc := range testCases {
+		if r := IsTimeout(tc.err); r != tc.timeout {
+			t.Errorf("expected IsTimeout(%v) to return %t, got %t", tc.err, tc.timeout, r)
+		}
+	}
+}
+
+func Test_IsTemporary(t *testing.T) {
+	t.Parallel()
+
+	if IsTemporary(nil) {
+		t.Error("nil error is not temporary")
+	}
+	if IsTemporary(Wrap(tempError{temporary: false}, "send")) {
+		t.Error("expected error not to be temporary")
+	}
+	if !IsTemporary(fmt.Errorf("send: %w", WithStack(tempError{temporary: true}))) {
+		t.Error("expected error to be temporary")
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/ainvaltin/exerr/internal/srcload"
+)
+
+const exerrPath = "github.com/ainvaltin/exerr"
+
+/*
+Entry describes a place in the source code where exerr error is created or where
+fields are attached to the error.
+*/
+type Entry struct {
+	Kind     string   `json:"kind"`              // name of the exerr func, ie "Errorf"
+	Message  string   `json:"message,omitempty"` // message or format string, when it is constant
+	Fields   []string `json:"fields,omitempty"`  // names of the fields attached
+	Package  string   `json:"package"`
+	Function string   `json:"function,omitempty"` // enclosing function
+	Location string   `json:"location"`           // file:line
+}
+
+// names of the exerr funcs and methods (as returned by types.Func.FullName)
+const (
+	fnNew          = exerrPath + ".New"
+	fnErrorf       = exerrPath + ".Errorf"
+	fnWrap         = exerrPath + ".Wrap"
+	fnWithStack    = exerrPath + ".WithStack"
+	fnAddField     = exerrPath + ".AddField"
+	fnWithFields   = exerrPath + ".WithFields"
+	fnWith         = exerrPath + ".With"
+	fnNewKey       = exerrPath + ".NewKey"
+	fnAnnotate     = exerrPath + ".Annotate"
+	fnAnnotateMsg  = exerrPath + ".AnnotateMsg"
+	fnScope        = exerrPath + ".Scope"
+	methodAddField = "(" + exerrPath + ".ErrorWithFields).AddField"
+	methodWith     = "(*" + exerrPath + ".Builder).With"
+	methodNew      = "(*" + exerrPath + ".Builder).New"
+	methodErrorf   = "(*" + exerrPath + ".Builder).Errorf"
+	methodWrap     = "(*" + exerrPath + ".Builder).Wrap"
+)
+
+/*
+collect returns catalog entries of the package, locations are relative to "root".
+*/
+func collect(pkg *srcload.Package, root string) []Entry {
+	c := &collector{pkg: pkg, root: root, inits: make(map[types.Object]ast.Expr), seen: make(map[*ast.CallExpr]bool)}
+	for _, f := range pkg.Files {
+		c.collectInits(f)
+	}
+	for _, f := range pkg.Files {
+		c.collectFile(f)
+	}
+	sort.SliceStable(c.entries, func(i, j int) bool { return c.entries[i].pos < c.entries[j].pos })
+	r := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		r = append(r, e.Entry)
+	}
+	return r
+}
+
+type collector struct {
+	pkg     *srcload.Package
+	root    string
+	inits   map[types.Object]ast.Expr // variable -> initial value
+	seen    map[*ast.CallExpr]bool    // calls already processed as part of a chain
+	entries []posEntry
+}
+
+type posEntry struct {
+	Entry
+	pos token.Pos
+}
+
+/*
+collectInits records the initial values of the variables so that the names of the
+typed keys and fields of the scoped builders can be resolved.
+*/
+func (c *collector) collectInits(f *ast.File) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, id := range n.Names {
+					c.inits[c.pkg.Info.Defs[id]] = n.Values[i]
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok && c.pkg.Info.Defs[id] != nil {
+						c.inits[c.pkg.Info.Defs[id]] = n.Rhs[i]
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (c *collector) collectFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		fn := ""
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			fn = funcName(fd)
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && !c.seen[call] {
+				if e, ok := c.entry(call); ok {
+					e.Package = c.pkg.Path
+					e.Function = fn
+					e.Location = c.location(call.Pos())
+					c.entries = append(c.entries, posEntry{Entry: e, pos: call.Pos()})
+				}
+			}
+			return true
+		})
+	}
+}
+
+/*
+entry returns catalog entry for the chain of exerr calls ending with "call". The
+chain is followed through the calls which attach fields to the error until the call
+which creates the error. When the error is not created in the chain the kind of the
+entry is the innermost call which attaches fields (ie "AddField").
+*/
+func (c *collector) entry(call *ast.CallExpr) (e Entry, ok bool) {
+	if !c.isChainCall(call) {
+		return e, false
+	}
+
+	var fields []string
+	for {
+		c.seen[call] = true
+		callee := c.calleeName(call)
+		var inner ast.Expr
+		switch callee {
+		case methodAddField:
+			fields = append(c.names(call.Args[:1]), fields...)
+			inner, e.Kind = call.Fun.(*ast.SelectorExpr).X, "AddField"
+		case fnAddField:
+			fields = append(c.names(call.Args[1:2]), fields...)
+			inner, e.Kind = call.Args[0], "AddField"
+		case fnWithFields:
+			fields = append(c.kvNames(call, 1), fields...)
+			inner, e.Kind = call.Args[0], "WithFields"
+		case fnWith:
+			fields = append(c.keyNames(call.Args[1:2]), fields...)
+			inner, e.Kind = call.Args[0], "With"
+		case fnAnnotate:
+			fields = append(c.kvNames(call, 1), fields...)
+			e.Kind = "Annotate"
+		case fnAnnotateMsg:
+			e.Kind, e.Message = "AnnotateMsg", c.constString(call.Args[1])
+		case fnNew:
+			e.Kind, e.Message = "New", c.constString(call.Args[0])
+		case fnErrorf:
+			e.Kind, e.Message = "Errorf", c.constString(call.Args[0])
+		case fnWrap:
+			e.Kind, e.Message = "Wrap", c.constString(call.Args[1])
+		case fnWithStack:
+			e.Kind = "WithStack"
+		case methodNew, methodErrorf, methodWrap:
+			e.Kind = "Scope." + call.Fun.(*ast.SelectorExpr).Sel.Name
+			if callee != methodWrap {
+				e.Message = c.constString(call.Args[0])
+			}
+			fields = append(c.scopeNames(call.Fun.(*ast.SelectorExpr).X), fields...)
+		}
+
+		ic, ok := ast.Unparen(inner).(*ast.CallExpr)
+		if inner == nil || !ok || !c.isChainCall(ic) {
+			break
+		}
+		call = ic
+	}
+	e.Fields = fields
+	return e, true
+}
+
+// isChainCall returns true when "call" is exerr call which creates or annotates error.
+func (c *collector) isChainCall(call *ast.CallExpr) bool {
+	switch c.calleeName(call) {
+	case methodAddField, fnAddField, fnWithFields, fnWith, fnAnnotate, fnAnnotateMsg,
+		fnNew, fnErrorf, fnWrap, fnWithStack, methodNew, methodErrorf, methodWrap:
+		return true
+	}
+	return false
+}
+
+// calleeName returns full name of the exerr func or method called, empty string for other calls.
+func (c *collector) calleeName(call *ast.CallExpr) string {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return ""
+	}
+	fn, ok := c.pkg.Info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != exerrPath {
+		return ""
+	}
+	return fn.Origin().FullName()
+}
+
+func (c *collector) constString(expr ast.Expr) string {
+	tv, ok := c.pkg.Info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return ""
+	}
+	return constant.StringVal(tv.Value)
+}
+
+// names returns constant field names, non-constant names are reported as "?".
+func (c *collector) names(args []ast.Expr) []string {
+	r := make([]string, 0, len(args))
+	for _, a := range args {
+		if s := c.constString(a); s != "" {
+			r = append(r, s)
+		} else {
+			r = append(r, "?")
+		}
+	}
+	return r
+}
+
+// kvNames returns field names of the list of alternating names and values starting at "start".
+func (c *collector) kvNames(call *ast.CallExpr, start int) (r []string) {
+	if call.Ellipsis.IsValid() {
+		return []string{"..."}
+	}
+	for i := start; i < len(call.Args); i += 2 {
+		r = append(r, c.names(call.Args[i:i+1])...)
+	}
+	return r
+}
+
+// keyNames resolves the names of the typed keys created by NewKey.
+func (c *collector) keyNames(args []ast.Expr) (r []string) {
+	for _, a := range args {
+		name := "?"
+		if init, ok := c.initOf(a).(*ast.CallExpr); ok && c.calleeName(init) == fnNewKey {
+			name = c.names(init.Args[:1])[0]
+		}
+		r = append(r, name)
+	}
+	return r
+}
+
+// scopeNames resolves field names of the scoped builder "expr".
+func (c *collector) scopeNames(expr ast.Expr) []string {
+	call, ok := c.initOf(expr).(*ast.CallExpr)
+	if !ok {
+		return []string{"..."}
+	}
+	switch c.calleeName(call) {
+	case fnScope:
+		return c.kvNames(call, 0)
+	case methodWith:
+		return append(c.scopeNames(call.Fun.(*ast.SelectorExpr).X), c.names(call.Args[:1])...)
+	}
+	return []string{"..."}
+}
+
+// initOf returns initial value of the variable "expr", expr itself when it is not a variable.
+func (c *collector) initOf(expr ast.Expr) ast.Expr {
+	expr = ast.Unparen(expr)
+	var id *ast.Ident
+	switch e := expr.(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return expr
+	}
+	if init, ok := c.inits[c.pkg.Info.Uses[id]]; ok {
+		return ast.Unparen(init)
+	}
+	return expr
+}
+
+func (c *collector) location(pos token.Pos) string {
+	p := c.pkg.Fset.Position(pos)
+	name := p.Filename
+	if rel, err := filepath.Rel(c.root, name); err == nil {
+		name = rel
+	}
+	return filepath.ToSlash(name) + ":" + strconv.Itoa(p.Line)
+}
+
+// funcName returns name of the function, for methods in the form "(*T).Method".
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	return "(" + types.ExprString(fd.Recv.List[0].Type) + ")." + fd.Name.Name
+}
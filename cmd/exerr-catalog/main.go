@@ -0,0 +1,111 @@
+/*
+Command exerr-catalog creates catalog of the errors the packages can produce: every
+place in the source code where exerr error is created (or fields are attached to an
+existing error) together with the message template and the names of the fields.
+
+Usage:
+
+	exerr-catalog [-format markdown|json] [packages]
+
+Packages are given as patterns understood by "go list", default is "./...". Source
+locations are relative to the current directory. The catalog is written to stdout
+and is sorted by location so it is suitable for diffing in code review.
+
+Message is included only when it is a constant, field names which are not constant
+are reported as "?" and names which can't be determined (ie fields passed as a slice)
+as "...".
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ainvaltin/exerr/internal/srcload"
+)
+
+func main() {
+	if err := run(".", os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("exerr-catalog", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "markdown", "output format, markdown or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "markdown" && *format != "json" {
+		return fmt.Errorf("unsupported output format %q", *format)
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	pkgs, err := srcload.Load(dir, patterns...)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	var entries []Entry
+	for _, pkg := range pkgs {
+		entries = append(entries, collect(pkg, root)...)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if entries == nil {
+			entries = []Entry{}
+		}
+		return enc.Encode(entries)
+	}
+	return writeMarkdown(stdout, entries)
+}
+
+func writeMarkdown(w io.Writer, entries []Entry) error {
+	b := &strings.Builder{}
+	b.WriteString("# Error catalog\n")
+	pkg := ""
+	for _, e := range entries {
+		if e.Package != pkg {
+			pkg = e.Package
+			fmt.Fprintf(b, "\n## %s\n\n", pkg)
+			b.WriteString("| Location | Function | Kind | Message | Fields |\n")
+			b.WriteString("|---|---|---|---|---|\n")
+		}
+		fields := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			fields = append(fields, code(f))
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n", e.Location, cell(e.Function), e.Kind, code(e.Message), strings.Join(fields, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// code formats "s" as Markdown code span usable in table cell.
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + cell(strings.ReplaceAll(s, "`", "'")) + "`"
+}
+
+// cell escapes characters which have special meaning in the table.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
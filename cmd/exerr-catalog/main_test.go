@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_run(t *testing.T) {
+	testCases := []struct {
+		format string
+		golden string
+	}{
+		{format: "markdown", golden: "app.md.golden"},
+		{format: "json", golden: "app.json.golden"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := run(".", []string{"-format", tc.format, "./testdata/app"}, out, os.Stderr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			name := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := os.WriteFile(name, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			exp, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(exp, out.Bytes()) {
+				t.Errorf("output doesn't match %s:\n%s", name, out.String())
+			}
+		})
+	}
+}
+
+func Test_run_invalid_args(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := run(".", []string{"-format", "xml"}, out, out); err == nil || err.Error() != `unsupported output format "xml"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := run(".", []string{"./testdata/nonexisting"}, out, out); err == nil {
+		t.Error("expected error for invalid package")
+	}
+}
+
+func Test_writeMarkdown(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := writeMarkdown(out, []Entry{
+		{Kind: "Errorf", Message: "a | b `c`", Fields: []string{"x"}, Package: "p", Function: "f", Location: "p.go:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "# Error catalog\n\n## p\n\n| Location | Function | Kind | Message | Fields |\n|---|---|---|---|---|\n| p.go:1 | f | Errorf | `a \\| b 'c'` | `x` |\n"
+	if out.String() != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, out.String())
+	}
+}
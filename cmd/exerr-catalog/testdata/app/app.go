@@ -0,0 +1,45 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ainvaltin/exerr"
+)
+
+var ErrNotFound = errors.New("not found")
+
+var UserID = exerr.NewKey[int64]("user_id")
+
+type Store struct{}
+
+func (s *Store) Load(id int64) (err error) {
+	defer exerr.Annotate(&err, "op", "load")
+
+	if id == 0 {
+		return exerr.New("invalid id").AddField("id", id)
+	}
+	if err := s.query(); err != nil {
+		return exerr.Errorf("loading %d: %w", id, err).AddField("table", "users").AddField("id", id)
+	}
+	return exerr.With(exerr.Wrap(ErrNotFound, "load"), UserID, id)
+}
+
+func (s *Store) query() error {
+	b := exerr.Scope("op", "query")
+	if err := s.exec(); err != nil {
+		return b.With("sql", "select 1").Errorf("query failed: %w", err)
+	}
+	return b.Wrap(fmt.Errorf("no rows | %d", 0))
+}
+
+func (s *Store) exec() error {
+	name := "dynamic"
+	err := exerr.WithStack(ErrNotFound)
+	return exerr.WithFields(exerr.AddField(err, name, 1), "retry", true)
+}
+
+func annotate(err error) error {
+	exerr.AnnotateMsg(&err, "annotating %s", "x")
+	return exerr.AddField(err, "x", 1)
+}
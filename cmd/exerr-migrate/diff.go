@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lines splits "s" into lines, each line includes the terminating newline.
+func lines(s []byte) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	r := strings.SplitAfter(string(s), "\n")
+	if r[len(r)-1] == "" {
+		r = r[:len(r)-1]
+	}
+	return r
+}
+
+type editKind byte
+
+const (
+	editEqual  editKind = ' '
+	editDelete editKind = '-'
+	editInsert editKind = '+'
+)
+
+type edit struct {
+	kind editKind
+	line string
+}
+
+/*
+editScript returns shortest edit script transforming "a" into "b", using the Myers'
+O(ND) difference algorithm.
+*/
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, d, max)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(trace [][]int, a, b []string, d, max int) []edit {
+	var r []edit
+	x, y := len(a), len(b)
+	for ; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[max+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			r = append(r, edit{editEqual, a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				r = append(r, edit{editInsert, b[y]})
+			} else {
+				x--
+				r = append(r, edit{editDelete, a[x]})
+			}
+		}
+	}
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return r
+}
+
+/*
+hunks returns the differences of "a" and "b" as hunks of unified diff with "context"
+unchanged lines around changes.
+*/
+func hunks(a, b []string, context int) (r []string) {
+	edits := editScript(a, b)
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
+			i++
+			continue
+		}
+		// start of the hunk, include preceding context
+		start := max(i-context, 0)
+		// find the end of the hunk: change followed by more than 2*context equal lines
+		end, eq := i, 0
+		for j := i; j < len(edits) && eq <= 2*context; j++ {
+			if edits[j].kind == editEqual {
+				eq++
+			} else {
+				eq, end = 0, j+1
+			}
+		}
+		end = min(end+context, len(edits))
+
+		// line numbers (1 based) of the hunk start in "a" and "b"
+		aLine, bLine := 1, 1
+		for _, e := range edits[:start] {
+			if e.kind != editInsert {
+				aLine++
+			}
+			if e.kind != editDelete {
+				bLine++
+			}
+		}
+		sb := &strings.Builder{}
+		aCnt, bCnt := 0, 0
+		for _, e := range edits[start:end] {
+			if e.kind != editInsert {
+				aCnt++
+			}
+			if e.kind != editDelete {
+				bCnt++
+			}
+			sb.WriteByte(byte(e.kind))
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		if aCnt == 0 {
+			aLine--
+		}
+		if bCnt == 0 {
+			bLine--
+		}
+		r = append(r, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aLine, aCnt, bLine, bCnt)+sb.String())
+		i = end
+	}
+	return r
+}
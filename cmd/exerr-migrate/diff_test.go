@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_hunks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a, b string
+		exp  string
+	}{
+		{a: "a\nb\n", b: "a\nb\n", exp: ""},
+		{a: "", b: "a\n", exp: "@@ -0,0 +1,1 @@\n+a\n"},
+		{a: "a\n", b: "", exp: "@@ -1,1 +0,0 @@\n-a\n"},
+		{a: "a\nb\nc\n", b: "a\nx\nc\n", exp: "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{a: "a\nb", b: "a\nc", exp: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		{
+			// changes far apart produce separate hunks
+			a:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:   "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			exp: "@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+		{
+			// changes close to each other are merged into single hunk
+			a:   "1\n2\n3\n4\n5\n",
+			b:   "x\n2\n3\n4\ny\n",
+			exp: "@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n 4\n-5\n+y\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		if s := strings.Join(hunks(lines([]byte(tc.a)), lines([]byte(tc.b)), 3), ""); s != tc.exp {
+			t.Errorf("diff of %q and %q:\nexpected\n%s\ngot\n%s", tc.a, tc.b, tc.exp, s)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Command exerr-migrate rewrites fmt.Errorf and github.com/pkg/errors call sites to use
+the exerr package instead:
+
+  - fmt.Errorf, errors.New, errors.Errorf -> exerr.Errorf, exerr.New;
+  - errors.Wrap, errors.WithStack -> exerr.Wrap, exerr.WithStack;
+  - errors.WithMessage -> exerr.Wrap;
+  - errors.Wrapf(err, format, args...) -> exerr.Wrap(err, fmt.Sprintf(format, args...)).
+
+Calls in package level variable initializers are left as is as these are most likely
+sentinel errors. Imports are updated accordingly and the result is gofmt-ed. Other
+pkg/errors funcs (ie Cause) are not rewritten.
+
+Usage:
+
+	exerr-migrate [-l] [-w | -d] [path ...]
+
+Paths are Go files or directories (processed recursively, skipping vendor, testdata
+and hidden directories), default is the current directory. Without -w or -d the
+rewritten source of the changed files is written to stdout.
+
+The tool works on syntax level only, ie when a local variable shadows the name of
+the imported package the call is still rewritten. Review the changes before
+committing!
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("exerr-migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose content would change")
+	write := flags.Bool("w", false, "write result to the (source) file instead of stdout")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *write && *diff {
+		return fmt.Errorf("flags -w and -d are mutually exclusive")
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	process := func(name string) error {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		out, changed, err := rewrite(name, src)
+		if err != nil || !changed {
+			return err
+		}
+		if *list {
+			fmt.Fprintln(stdout, name)
+		}
+		switch {
+		case *write:
+			return os.WriteFile(name, out, 0o644)
+		case *diff:
+			_, err = stdout.Write(unifiedDiff(name, src, out))
+			return err
+		case !*list:
+			_, err = stdout.Write(out)
+			return err
+		}
+		return nil
+	}
+
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			if err := process(path); err != nil {
+				return err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if base := d.Name(); name != path && (base == "vendor" || base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(name) != ".go" {
+				return nil
+			}
+			return process(name)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+unifiedDiff returns difference between "a" and "b" in unified diff format, empty
+result when they are equal.
+*/
+func unifiedDiff(name string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "--- %s.orig\n+++ %s\n", name, name)
+	for _, h := range hunks(lines(a), lines(b), 3) {
+		out.WriteString(h)
+	}
+	return out.Bytes()
+}
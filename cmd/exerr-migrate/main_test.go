@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_rewrite(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files found")
+	}
+
+	for _, name := range files {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			src, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, _, err := rewrite(name, src)
+			if err != nil {
+				t.Fatalf("rewrite failed: %v", err)
+			}
+
+			golden := strings.TrimSuffix(name, ".input") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, out, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			exp, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(exp, out) {
+				t.Errorf("output doesn't match %s:\n%s", golden, out)
+			}
+
+			// rewriting the result again must not change it
+			again, changed, err := rewrite(golden, out)
+			if err != nil {
+				t.Fatalf("rewriting the result failed: %v", err)
+			}
+			if changed && !bytes.Equal(again, out) {
+				t.Errorf("rewrite is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func Test_rewrite_invalid_source(t *testing.T) {
+	if _, _, err := rewrite("x.go", []byte("package")); err == nil {
+		t.Error("expected error for invalid source")
+	}
+}
+
+func Test_run(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile(filepath.Join("testdata", "fmtonly.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(name, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// file in the testdata directory must be skipped
+	if err := os.Mkdir(filepath.Join(dir, "testdata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "testdata", "b.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("diff", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := run([]string{"-d", dir}, out, out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := "--- " + name + ".orig\n+++ " + name + "\n" +
+			"@@ -1,10 +1,10 @@\n" +
+			" package app\n \n" +
+			"-import \"fmt\"\n+import \"github.com/ainvaltin/exerr\"\n" +
+			" \n func check(n int) error {\n \tif n < 0 {\n" +
+			"-\t\treturn fmt.Errorf(\"negative value %d\", n)\n+\t\treturn exerr.Errorf(\"negative value %d\", n)\n" +
+			" \t}\n \treturn nil\n }\n"
+		if out.String() != exp {
+			t.Errorf("unexpected diff:\n%s", out)
+		}
+	})
+
+	t.Run("list and write", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := run([]string{"-l", "-w", dir}, out, out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != name+"\n" {
+			t.Errorf("unexpected output: %q", out)
+		}
+		golden, err := os.ReadFile(filepath.Join("testdata", "fmtonly.golden"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(name); !bytes.Equal(b, golden) {
+			t.Errorf("unexpected file content:\n%s", b)
+		}
+		if b, _ := os.ReadFile(filepath.Join(dir, "testdata", "b.go")); !bytes.Equal(b, src) {
+			t.Error("file in testdata directory was modified")
+		}
+	})
+
+	t.Run("invalid args", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := run([]string{"-w", "-d"}, out, out); err == nil {
+			t.Error("expected error for conflicting flags")
+		}
+		if err := run([]string{filepath.Join(dir, "nonexisting.go")}, out, out); err == nil {
+			t.Error("expected error for nonexisting file")
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	exerrPath     = "github.com/ainvaltin/exerr"
+	pkgErrorsPath = "github.com/pkg/errors"
+)
+
+/*
+rewrite migrates calls of fmt.Errorf and pkg/errors funcs in the Go source "src"
+to their exerr equivalents. Returns formatted source, changed is false when there
+was nothing to rewrite (and the source is returned unchanged).
+
+Calls in package level variable initializers are not rewritten as these are (most
+likely) sentinel errors for which exerr shouldn't be used.
+*/
+func rewrite(filename string, src []byte) (out []byte, changed bool, err error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	r := &rewriter{
+		fmtName:    importName(f, "fmt"),
+		errorsName: importName(f, pkgErrorsPath),
+		exerrName:  importName(f, exerrPath),
+	}
+	if r.fmtName == "" && r.errorsName == "" {
+		return src, false, nil
+	}
+	if r.exerrName == "" {
+		r.exerrName = "exerr"
+	}
+
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok == token.DEFINE {
+					r.rewriteDefinition(n.Rhs)
+				}
+			case *ast.ValueSpec:
+				if n.Type == nil {
+					r.rewriteDefinition(n.Values)
+				}
+			case *ast.CallExpr:
+				r.rewriteCall(n)
+			}
+			return true
+		})
+	}
+	if r.count == 0 {
+		return src, false, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, f); err != nil {
+		return nil, false, err
+	}
+	if out, err = fixImports(buf.Bytes(), r.needFmt); err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+type rewriter struct {
+	fmtName    string // local names of the imports, empty when not imported
+	errorsName string
+	exerrName  string
+	needFmt    bool                   // fmt.Sprintf is used by rewritten code
+	count      int                    // number of rewritten calls
+	done       map[*ast.CallExpr]bool // calls which have been already visited
+}
+
+/*
+rewriteDefinition rewrites calls which are values of the variable definition without
+explicit type (x := f() or var x = f()). As exerr funcs return ErrorWithFields rather
+than error the rewritten call is converted to error, otherwise the type of the variable
+would change and assigning other errors to it would fail to compile.
+*/
+func (r *rewriter) rewriteDefinition(values []ast.Expr) {
+	for i, v := range values {
+		if call, ok := v.(*ast.CallExpr); ok && r.rewriteCall(call) {
+			values[i] = &ast.CallExpr{
+				Fun:    &ast.Ident{NamePos: call.Pos(), Name: "error"},
+				Lparen: call.Pos(),
+				Args:   []ast.Expr{call},
+				Rparen: call.End(),
+			}
+		}
+	}
+}
+
+/*
+rewriteCall rewrites the call when it is fmt.Errorf or pkg/errors call, returns true
+when the call was rewritten. Each call is rewritten only once.
+*/
+func (r *rewriter) rewriteCall(call *ast.CallExpr) bool {
+	if r.done[call] {
+		return false
+	}
+	if r.done == nil {
+		r.done = make(map[*ast.CallExpr]bool)
+	}
+	r.done[call] = true
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Obj != nil {
+		return false // not a package qualifier
+	}
+	count := r.count
+
+	switch {
+	case r.fmtName != "" && pkg.Name == r.fmtName && sel.Sel.Name == "Errorf":
+		r.setFunc(sel, "Errorf")
+	case r.errorsName != "" && pkg.Name == r.errorsName:
+		switch sel.Sel.Name {
+		case "New", "Errorf", "Wrap", "WithStack":
+			r.setFunc(sel, sel.Sel.Name)
+		case "WithMessage":
+			// WithMessage doesn't add stack but Wrap is the closest equivalent
+			r.setFunc(sel, "Wrap")
+		case "Wrapf", "WithMessagef":
+			// errors.Wrapf(err, format, args...) -> exerr.Wrap(err, fmt.Sprintf(format, args...))
+			// to preserve the semantics of returning nil for nil error
+			if len(call.Args) < 2 {
+				return false
+			}
+			r.setFunc(sel, "Wrap")
+			fmtName := r.fmtName
+			if fmtName == "" {
+				fmtName, r.needFmt = "fmt", true
+			}
+			sprintf := &ast.CallExpr{
+				Fun:      &ast.SelectorExpr{X: ast.NewIdent(fmtName), Sel: ast.NewIdent("Sprintf")},
+				Lparen:   call.Args[1].Pos(),
+				Args:     call.Args[1:],
+				Ellipsis: call.Ellipsis,
+				Rparen:   call.Rparen,
+			}
+			call.Args = []ast.Expr{call.Args[0], sprintf}
+			call.Ellipsis = token.NoPos
+		}
+	}
+	return r.count > count
+}
+
+func (r *rewriter) setFunc(sel *ast.SelectorExpr, name string) {
+	sel.X = &ast.Ident{NamePos: sel.X.Pos(), Name: r.exerrName}
+	sel.Sel = &ast.Ident{NamePos: sel.Sel.Pos(), Name: name}
+	r.count++
+}
+
+/*
+importName returns the name under which package "path" is imported in the file,
+empty string when it is not imported (or imported as "_" or ".").
+*/
+func importName(f *ast.File, path string) string {
+	for _, is := range f.Imports {
+		if p, _ := strconv.Unquote(is.Path.Value); p != path {
+			continue
+		}
+		if is.Name != nil {
+			if is.Name.Name == "_" || is.Name.Name == "." {
+				return ""
+			}
+			return is.Name.Name
+		}
+		if path == pkgErrorsPath {
+			return "errors"
+		}
+		return path[strings.LastIndexByte(path, '/')+1:]
+	}
+	return ""
+}
+
+// isUsed returns true when package "name" is referenced in the file.
+func isUsed(f *ast.File, name string) (used bool) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+/*
+fixImports adds exerr (and fmt when "needFmt" is true) import to the source "src"
+and removes imports of fmt and pkg/errors when these are not used anymore.
+
+Import declarations are edited textually so that the grouping of the imports and
+comments are preserved as much as possible.
+*/
+func fixImports(src []byte, needFmt bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	// lineEnd returns offset after the end of the line containing "p"
+	lineEnd := func(p token.Pos) int {
+		if i := bytes.IndexByte(src[offset(p):], '\n'); i >= 0 {
+			return offset(p) + i + 1
+		}
+		return len(src)
+	}
+	lineStart := func(p token.Pos) int {
+		return bytes.LastIndexByte(src[:offset(p)], '\n') + 1
+	}
+
+	var edits []textEdit
+	var decls []*ast.GenDecl
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			decls = append(decls, gd)
+		}
+	}
+
+	// remove unused imports
+	for _, path := range []string{"fmt", pkgErrorsPath} {
+		name := importName(f, path)
+		if name == "" || isUsed(f, name) {
+			continue
+		}
+		for _, gd := range decls {
+			for i, spec := range gd.Specs {
+				if p, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value); p != path {
+					continue
+				}
+				declEnd := gd.End()
+				gd.Specs = append(gd.Specs[:i], gd.Specs[i+1:]...)
+				if len(gd.Specs) == 0 {
+					edits = append(edits, textEdit{lineStart(gd.Pos()), lineEnd(declEnd), ""})
+				} else {
+					edits = append(edits, textEdit{lineStart(spec.Pos()), lineEnd(spec.End()), ""})
+				}
+				break
+			}
+		}
+	}
+
+	// add missing imports, into the group of the "similar" imports (std lib or not)
+	var add []string
+	if importName(f, exerrPath) == "" {
+		add = append(add, exerrPath)
+	}
+	if needFmt && importName(f, "fmt") == "" {
+		add = append(add, "fmt")
+	}
+	for _, path := range add {
+		line := strconv.Quote(path) + "\n"
+		var group *ast.GenDecl // last parenthesized import declaration
+		var after ast.Spec     // spec after which to insert the new import
+		for _, gd := range decls {
+			if len(gd.Specs) == 0 {
+				continue
+			}
+			if gd.Lparen.IsValid() {
+				group = gd
+			}
+			for _, spec := range gd.Specs {
+				p, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+				if isStd(p) == isStd(path) {
+					after = spec
+				}
+			}
+		}
+		switch {
+		case after != nil:
+			edits = append(edits, textEdit{lineEnd(after.End()), lineEnd(after.End()), line})
+		case group != nil && isStd(path):
+			edits = append(edits, textEdit{lineEnd(group.Lparen), lineEnd(group.Lparen), line + "\n"})
+		case group != nil:
+			edits = append(edits, textEdit{lineStart(group.Rparen), lineStart(group.Rparen), "\n" + line})
+		default:
+			edits = append(edits, textEdit{lineEnd(f.Name.End()), lineEnd(f.Name.End()), "\nimport " + line})
+		}
+	}
+
+	// apply edits, starting from the end of the source so that offsets remain valid
+	slices.SortStableFunc(edits, func(a, b textEdit) int { return b.start - a.start })
+	out := slices.Clone(src)
+	for _, e := range edits {
+		out = slices.Concat(out[:e.start], []byte(e.text), out[e.end:])
+	}
+	return format.Source(out)
+}
+
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// isStd returns true when "path" is (most likely) import path of the standard library package.
+func isStd(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
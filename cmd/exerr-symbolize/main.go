@@ -0,0 +1,167 @@
+/*
+Command exerr-symbolize replaces unsymbolized stack traces (see exerr.WithRawStack)
+in JSON logs with list of frames (function, file and line).
+
+Usage:
+
+	exerr-symbolize -binary path/to/executable [file ...]
+
+Input is read from the files given as arguments (or from stdin when there is none),
+one JSON object per line, and written to stdout. Every object with "raw_stack" key
+(on any level of nesting) whose build ID matches the binary has the key replaced with
+"stack". Lines which are not JSON are copied unchanged.
+
+Calls which were inlined by the compiler are reported as part of the function they
+were inlined into.
+*/
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ainvaltin/exerr"
+	"github.com/ainvaltin/exerr/internal/gobin"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("exerr-symbolize", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	binary := fs.String("binary", "", "path to the executable which produced the logs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *binary == "" {
+		return errors.New("path to the binary must be given using -binary flag")
+	}
+
+	bin, err := gobin.Open(*binary)
+	if err != nil {
+		return fmt.Errorf("reading binary %s: %w", *binary, err)
+	}
+	s := &symbolizer{bin: bin, warn: stderr}
+
+	out := bufio.NewWriter(stdout)
+	if fs.NArg() == 0 {
+		if err := s.copy(out, stdin); err != nil {
+			return err
+		}
+	}
+	for _, name := range fs.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = s.copy(out, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("processing %s: %w", name, err)
+		}
+	}
+	return out.Flush()
+}
+
+type symbolizer struct {
+	bin  *gobin.File
+	warn io.Writer
+}
+
+func (s *symbolizer) copy(w io.Writer, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if _, err := w.Write(s.line(sc.Bytes())); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+/*
+line returns the log line with raw stacks symbolized, when the line doesn't contain
+raw stacks it is returned unchanged.
+*/
+func (s *symbolizer) line(b []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return b
+	}
+	if !s.rewrite(v) {
+		return b
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintf(s.warn, "encoding symbolized line: %v\n", err)
+		return b
+	}
+	return out
+}
+
+// rewrite replaces raw stacks in "v", returns true if anything was changed.
+func (s *symbolizer) rewrite(v any) (changed bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if k == "raw_stack" {
+				if frames, ok := s.symbolize(item); ok {
+					delete(v, k)
+					v["stack"] = frames
+					changed = true
+				}
+				continue
+			}
+			changed = s.rewrite(item) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = s.rewrite(item) || changed
+		}
+	}
+	return changed
+}
+
+func (s *symbolizer) symbolize(v any) ([]exerr.Frame, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var rs exerr.RawStack
+	if err := json.Unmarshal(b, &rs); err != nil {
+		fmt.Fprintf(s.warn, "invalid raw stack: %v\n", err)
+		return nil, false
+	}
+	if rs.BuildID != s.bin.BuildID {
+		fmt.Fprintf(s.warn, "build ID of the stack %q doesn't match the binary %q\n", rs.BuildID, s.bin.BuildID)
+		return nil, false
+	}
+
+	frames := make([]exerr.Frame, 0, len(rs.PCs))
+	for _, pc := range rs.PCs {
+		// PCs are return addresses, subtract one to get the address of the call instruction
+		file, line, fn := s.bin.Table.PCToLine(uint64(pc) - rs.LoadOffset - 1)
+		f := exerr.Frame{Function: "?", File: file, Line: line}
+		if fn != nil {
+			f.Function = fn.Name
+		}
+		frames = append(frames, f)
+	}
+	return frames, true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+//go:noinline
+func createError() error {
+	return exerr.New("some error").AddField("foo", 42)
+}
+
+func Test_run(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("locating test binary: %v", err)
+	}
+
+	rerr := createError()
+	report := exerr.NewErrorReport(rerr, exerr.WithRawStack())
+	if report.Raw == nil {
+		t.Fatal("expected report to contain raw stack")
+	}
+	if report.Raw.BuildID == "" {
+		t.Fatal("expected raw stack to have build ID")
+	}
+	b, err := json.Marshal(map[string]any{"level": "error", "error": report})
+	if err != nil {
+		t.Fatalf("encoding log record: %v", err)
+	}
+
+	input := strings.Join([]string{
+		"not JSON line",
+		string(b),
+		`{"msg":"no stack here"}`,
+		`{"raw_stack":{"build_id":"foobar","pcs":[1,2,3]}}`,
+	}, "\n")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := run([]string{"-binary", exe}, strings.NewReader(input), stdout, stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 output lines, got %d:\n%s", len(lines), stdout.String())
+	}
+	if lines[0] != "not JSON line" || lines[2] != `{"msg":"no stack here"}` {
+		t.Errorf("lines without raw stack must be copied as is, got\n%s", stdout.String())
+	}
+	// build ID doesn't match, line must be unchanged and warning written
+	if lines[3] != `{"raw_stack":{"build_id":"foobar","pcs":[1,2,3]}}` {
+		t.Errorf("line with foreign build ID was modified: %s", lines[3])
+	}
+	if !strings.Contains(stderr.String(), `build ID of the stack "foobar" doesn't match`) {
+		t.Errorf("expected warning about build ID mismatch, got %q", stderr.String())
+	}
+
+	var rec struct {
+		Level string `json:"level"`
+		Error struct {
+			Message string         `json:"message"`
+			Fields  map[string]any `json:"fields"`
+			Stack   []exerr.Frame  `json:"stack"`
+			Raw     any            `json:"raw_stack"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("decoding symbolized line: %v", err)
+	}
+	if rec.Level != "error" || rec.Error.Message != "some error" || rec.Error.Fields["foo"] != 42.0 {
+		t.Errorf("unexpected content of the symbolized record: %s", lines[1])
+	}
+	if rec.Error.Raw != nil {
+		t.Errorf("expected raw stack to be removed, got %v", rec.Error.Raw)
+	}
+	if exp := exerr.Frames(rerr); !reflect.DeepEqual(exp, rec.Error.Stack) {
+		t.Errorf("expected stack\n%v\ngot\n%v", exp, rec.Error.Stack)
+	}
+}
+
+func Test_run_invalid_args(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := run(nil, strings.NewReader(""), stdout, stderr); err == nil || err.Error() != "path to the binary must be given using -binary flag" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "foo")
+	if err := os.WriteFile(name, []byte("not ELF"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-binary", name}, strings.NewReader(""), stdout, stderr); err == nil {
+		t.Error("expected error for invalid binary")
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Command exerrcheck reports misuse of the exerr package:
+  - exerr.New or exerr.Errorf used to create sentinel error (in package level
+    variable initializer);
+  - field name which is not constant or not in snake_case;
+  - exerr.AddField (or exerr.With) called with error which is (possibly) nil;
+  - the same field name added more than once in a chain of calls.
+
+Usage:
+
+	exerrcheck [packages]
+
+Packages are given as patterns understood by "go list", default is "./...". Test
+files are not checked. Exit code is 3 when any problems were found.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ainvaltin/exerr/internal/exerrcheck"
+	"github.com/ainvaltin/exerr/internal/srcload"
+)
+
+func main() {
+	n, err := run(".", os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if n > 0 {
+		os.Exit(3)
+	}
+}
+
+// run checks packages and writes diagnostics to "out", returns number of diagnostics.
+func run(dir string, patterns []string, out io.Writer) (int, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	pkgs, err := srcload.Load(dir, patterns...)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for _, pkg := range pkgs {
+		for _, d := range exerrcheck.Run(pkg) {
+			fmt.Fprintf(out, "%s: %s\n", pkg.Fset.Position(d.Pos), d.Message)
+			cnt++
+		}
+	}
+	return cnt, nil
+}
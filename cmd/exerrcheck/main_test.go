@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_run(t *testing.T) {
+	t.Run("problems found", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		n, err := run(".", []string{"./testdata/a"}, out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 diagnostics, got %d", n)
+		}
+
+		file := filepath.Join("testdata", "a", "a.go")
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		exp := []string{
+			file + `:5:19: exerr.New should not be used to create sentinel errors, use errors.New instead`,
+			file + `:8:29: field name "userID" is not in snake_case`,
+		}
+		if len(lines) != len(exp) {
+			t.Fatalf("expected %d lines of output, got:\n%s", len(exp), out.String())
+		}
+		for i, l := range lines {
+			if !strings.HasSuffix(l, exp[i]) {
+				t.Errorf("expected line %d to end with\n%s\ngot\n%s", i, exp[i], l)
+			}
+		}
+	})
+
+	t.Run("exerr package is not checked", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		n, err := run("../..", []string{"."}, out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("expected no diagnostics, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("invalid package", func(t *testing.T) {
+		if _, err := run(".", []string{"./testdata/nonexisting"}, &bytes.Buffer{}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,9 @@
+package a
+
+import "github.com/ainvaltin/exerr"
+
+var ErrSentinel = exerr.New("sentinel")
+
+func F(err error) error {
+	return exerr.AddField(err, "userID", 1)
+}
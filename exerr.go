@@ -3,12 +3,44 @@ package exerr
 import (
 	"errors"
 	"runtime"
+	"sync/atomic"
 )
 
+/*
+CaptureMode determines how much of the call stack is captured when error is created,
+see [SetCaptureMode].
+*/
+type CaptureMode int32
+
+const (
+	CaptureStack      CaptureMode = iota // capture up to 32 frames of the call stack (default)
+	CaptureOriginOnly                    // capture only the location where the error was created
+)
+
+var captureMode atomic.Int32
+
+/*
+SetCaptureMode sets how much of the call stack is captured by errors created after
+the call. Capturing only the origin of the error is considerably cheaper and enough
+when only [Origin] of the error is needed.
+*/
+func SetCaptureMode(mode CaptureMode) {
+	captureMode.Store(int32(mode))
+}
+
 func newExErr(err error) *exErr {
-	pcs := make([]uintptr, 32)
-	n := runtime.Callers(3, pcs) // 1=newExErr; 2=Errorf|AddField|New; 3=user code
-	return &exErr{err: err, pcs: pcs[:n:n]}
+	return &exErr{err: err, pcs: callers(4)} // 1=callers; 2=newExErr; 3=Errorf|AddField|New; 4=user code
+}
+
+// callers captures the call stack according to the current capture mode.
+func callers(skip int) []uintptr {
+	size := 32
+	if CaptureMode(captureMode.Load()) == CaptureOriginOnly {
+		size = 1
+	}
+	pcs := make([]uintptr, size)
+	n := runtime.Callers(skip, pcs)
+	return pcs[:n:n]
 }
 
 // error with location info (stack trace) and optional metadata (fields).
@@ -16,16 +48,43 @@ type exErr struct {
 	err    error
 	pcs    []uintptr
 	fields map[string]any
+	format string      // format string of the Errorf call which created the error
+	args   []any       // non-error arguments of the Errorf call
+	logged atomic.Bool // the error has been logged, see MarkLogged
+	class  class       // explicit classification, see MarkRetryable and MarkPermanent
+}
+
+/*
+isNil returns true when "err" is nil or nil *exErr (which is not nil error!).
+*/
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	e, ok := err.(*exErr)
+	return ok && e == nil
 }
 
-func (e *exErr) As(target any) bool { return errors.As(e.err, target) }
+// Methods of exErr are nil receiver safe (except AddField) so that typed nil
+// accidentally ending up in an error interface doesn't cause panic.
 
-func (e *exErr) Is(target error) bool { return errors.Is(e.err, target) }
+func (e *exErr) As(target any) bool { return e != nil && errors.As(e.err, target) }
 
-func (e *exErr) Unwrap() error { return errors.Unwrap(e.err) }
+func (e *exErr) Is(target error) bool { return e != nil && errors.Is(e.err, target) }
+
+func (e *exErr) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	if inner, ok := e.err.(*exErr); ok {
+		// exErr wrapping exErr directly (ie MarkRetryable) is not a container of it
+		return inner
+	}
+	return errors.Unwrap(e.err)
+}
 
 func (e *exErr) Error() string {
-	if e.err == nil {
+	if e == nil || e.err == nil {
 		return "<nil>"
 	}
 	return e.err.Error()
@@ -41,6 +100,9 @@ func (e *exErr) AddField(name string, value any) ErrorWithFields {
 }
 
 func (e *exErr) FieldValue(name string) (any, bool) {
+	if e == nil {
+		return nil, false
+	}
 	v, ok := e.fields[name]
 	return v, ok
 }
@@ -50,9 +112,41 @@ Fields returns the name -> value map of the fields attached to the error.
 
 Should be considered to be read-only, ie do not modify!
 */
-func (e *exErr) Fields() map[string]any { return e.fields }
+func (e *exErr) Fields() map[string]any {
+	if e == nil {
+		return nil
+	}
+	return e.fields
+}
+
+// setTemplate stores format string and non-error arguments of the Errorf call.
+func (e *exErr) setTemplate(format string, args []any) {
+	e.format = format
+	for _, v := range args {
+		if _, ok := v.(error); !ok {
+			e.args = append(e.args, v)
+		}
+	}
+}
+
+/*
+Template returns the format string and non-error arguments of the [Errorf] call
+which created the error. Empty string is returned when the error was created by
+some other means.
+*/
+func (e *exErr) Template() (string, []any) {
+	if e == nil {
+		return "", nil
+	}
+	return e.format, e.args
+}
 
 /*
 PC returns return program counters of function invocations on the the place error was created.
 */
-func (e *exErr) PC() []uintptr { return e.pcs }
+func (e *exErr) PC() []uintptr {
+	if e == nil {
+		return nil
+	}
+	return e.pcs
+}
@@ -3,6 +3,7 @@ package exerr
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"testing"
 )
@@ -186,6 +187,26 @@ func Test_exErr_Is(t *testing.T) {
 	})
 }
 
+func Test_errors_Unwrap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("container is skipped", func(t *testing.T) {
+		err := Errorf("outer: %w", io.EOF)
+		if ue := errors.Unwrap(err); ue != io.EOF {
+			t.Errorf("expected Unwrap to skip the container, got %v", ue)
+		}
+	})
+
+	t.Run("exerr error wrapping exerr error", func(t *testing.T) {
+		inner := Errorf("inner: %w", io.EOF)
+		for _, err := range []error{Scope("op", "x").Wrap(inner), MarkPermanent(inner)} {
+			if ue := errors.Unwrap(err); ue != inner {
+				t.Errorf("expected Unwrap to return the wrapped exerr error, got %v", ue)
+			}
+		}
+	})
+}
+
 func Test_errors_Is(t *testing.T) {
 	t.Parallel()
 	// test that errors.Is returns expected results
@@ -268,3 +289,32 @@ func Test_errors_As(t *testing.T) {
 		}
 	})
 }
+
+func Test_SetCaptureMode(t *testing.T) {
+	// not parallel as capture mode is global setting
+	t.Cleanup(func() { SetCaptureMode(CaptureStack) })
+
+	err := New("full stack").(*exErr)
+	if n := len(err.PC()); n < 2 {
+		t.Errorf("expected full stack to be captured, got %d frames", n)
+	}
+
+	SetCaptureMode(CaptureOriginOnly)
+	err = New("origin only").(*exErr)
+	if n := len(err.PC()); n != 1 {
+		t.Fatalf("expected single frame to be captured, got %d", n)
+	}
+	f, ok := Origin(err)
+	if !ok {
+		t.Fatal("expected origin to be found")
+	}
+	if f.Function != "github.com/ainvaltin/exerr.Test_SetCaptureMode" {
+		t.Errorf("unexpected origin function %q", f.Function)
+	}
+
+	SetCaptureMode(CaptureStack)
+	err = New("full stack").(*exErr)
+	if n := len(err.PC()); n < 2 {
+		t.Errorf("expected full stack to be captured, got %d frames", n)
+	}
+}
@@ -0,0 +1,154 @@
+package exerrtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+Matcher checks single error of the chain, see [RequireChain].
+*/
+type Matcher interface {
+	Match(err error) bool
+	String() string // description of the expectation, used in failure message
+}
+
+type matcher struct {
+	match func(error) bool
+	desc  string
+}
+
+func (m matcher) Match(err error) bool { return m.match(err) }
+
+func (m matcher) String() string { return m.desc }
+
+// Any matches any error.
+func Any() Matcher {
+	return matcher{match: func(error) bool { return true }, desc: "any error"}
+}
+
+// Message matches error whose message is "msg".
+func Message(msg string) Matcher {
+	return matcher{
+		match: func(err error) bool { return err.Error() == msg },
+		desc:  fmt.Sprintf("message %q", msg),
+	}
+}
+
+// MessageContains matches error whose message contains "s".
+func MessageContains(s string) Matcher {
+	return matcher{
+		match: func(err error) bool { return strings.Contains(err.Error(), s) },
+		desc:  fmt.Sprintf("message containing %q", s),
+	}
+}
+
+// Same matches the error "target" itself (ie sentinel error).
+func Same(target error) Matcher {
+	return matcher{
+		match: func(err error) bool { return err == target },
+		desc:  fmt.Sprintf("error %q (%T)", target, target),
+	}
+}
+
+/*
+Type matches error which is of type T (or implements T when it is interface), ie
+Type[exerr.ErrorWithFields]() matches errors created by exerr.
+*/
+func Type[T any]() Matcher {
+	return matcher{
+		match: func(err error) bool { _, ok := err.(T); return ok },
+		desc:  "error of type " + reflect.TypeFor[T]().String(),
+	}
+}
+
+/*
+Field matches error which has field "name" with value "value" attached directly
+(not to the errors it wraps).
+*/
+func Field(name string, value any) Matcher {
+	return matcher{
+		match: func(err error) bool {
+			fv, ok := err.(interface{ FieldValue(string) (any, bool) })
+			if !ok {
+				return false
+			}
+			v, ok := fv.FieldValue(name)
+			return ok && reflect.DeepEqual(v, value)
+		},
+		desc: fmt.Sprintf("error with field %s = %s", name, formatValue(value)),
+	}
+}
+
+/*
+AllOf matches error which is matched by all the "matchers".
+*/
+func AllOf(matchers ...Matcher) Matcher {
+	desc := make([]string, len(matchers))
+	for i, m := range matchers {
+		desc[i] = m.String()
+	}
+	return matcher{
+		match: func(err error) bool {
+			for _, m := range matchers {
+				if !m.Match(err) {
+					return false
+				}
+			}
+			return true
+		},
+		desc: strings.Join(desc, " and "),
+	}
+}
+
+/*
+RequireChain checks that the chain of the error "err" consists of errors matched by
+"matchers", in the order of [exerr.Walk] (outermost error first, depth-first for multi
+errors). The number of errors in the chain must be equal to the number of matchers.
+
+	exerrtest.RequireChain(t, err,
+		exerrtest.MessageContains("loading config"),
+		exerrtest.Type[*fs.PathError](),
+		exerrtest.Same(fs.ErrNotExist),
+	)
+*/
+func RequireChain(t testing.TB, err error, matchers ...Matcher) {
+	t.Helper()
+	var chain []error
+	var depths []int
+	for depth, e := range exerr.All(err) {
+		chain = append(chain, e)
+		depths = append(depths, depth)
+	}
+
+	b := &strings.Builder{}
+	for i := range max(len(chain), len(matchers)) {
+		switch {
+		case i >= len(chain):
+			fmt.Fprintf(b, "  [%d] missing error, want %s\n", i, matchers[i])
+		case i >= len(matchers):
+			fmt.Fprintf(b, "  [%d] unexpected error %s\n", i, describe(chain[i]))
+		case !matchers[i].Match(chain[i]):
+			fmt.Fprintf(b, "  [%d] %s, want %s\n", i, describe(chain[i]), matchers[i])
+		}
+	}
+	if b.Len() != 0 {
+		t.Fatalf("error chain doesn't match:\n%schain:\n%s", b, describeChain(chain, depths))
+	}
+}
+
+func describe(err error) string {
+	return fmt.Sprintf("%q (%T)", err.Error(), err)
+}
+
+func describeChain(chain []error, depths []int) string {
+	b := &strings.Builder{}
+	for i, e := range chain {
+		fmt.Fprintf(b, "  [%d] %s%s\n", i, strings.Repeat("  ", depths[i]), describe(e))
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package exerrtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func Test_RequireChain(t *testing.T) {
+	t.Parallel()
+
+	pathErr := &fs.PathError{Op: "open", Path: "cfg.json", Err: fs.ErrNotExist}
+	err := fmt.Errorf("loading config: %w", exerr.Wrap(pathErr, "reading").AddField("file", "cfg.json"))
+
+	t.Run("matching chain", func(t *testing.T) {
+		msg := run(t, func(tb testing.TB) {
+			RequireChain(tb, err,
+				AllOf(MessageContains("loading config"), Type[error]()),
+				AllOf(Type[exerr.ErrorWithFields](), Field("file", "cfg.json")),
+				Type[*fs.PathError](),
+				Same(fs.ErrNotExist),
+			)
+		})
+		if msg != "" {
+			t.Errorf("unexpected failure: %s", msg)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		msg := run(t, func(tb testing.TB) {
+			RequireChain(tb, err, Any(), Field("file", "x"), Message("open cfg.json: file does not exist"))
+		})
+		expectFailure(t, msg,
+			`[1] "reading: open cfg.json: file does not exist" (*exerr.exErr), want error with field file = "x" (string)`,
+			"[3] unexpected error \"file does not exist\" (*errors.errorString)",
+			"chain:\n  [0] \"loading config: reading: open cfg.json: file does not exist\" (*fmt.wrapError)\n  [1]   \"reading:",
+		)
+	})
+
+	t.Run("missing errors", func(t *testing.T) {
+		msg := run(t, func(tb testing.TB) { RequireChain(tb, io.EOF, Same(io.EOF), Any()) })
+		expectFailure(t, msg, "[1] missing error, want any error")
+	})
+
+	t.Run("multi error", func(t *testing.T) {
+		msg := run(t, func(tb testing.TB) {
+			RequireChain(tb, errors.Join(io.EOF, io.ErrUnexpectedEOF), Any(), Same(io.EOF), Same(io.ErrUnexpectedEOF))
+		})
+		if msg != "" {
+			t.Errorf("unexpected failure: %s", msg)
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		if msg := run(t, func(tb testing.TB) { RequireChain(tb, nil) }); msg != "" {
+			t.Errorf("unexpected failure: %s", msg)
+		}
+		msg := run(t, func(tb testing.TB) { RequireChain(tb, nil, Any()) })
+		expectFailure(t, msg, "[0] missing error")
+	})
+}
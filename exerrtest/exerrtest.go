@@ -0,0 +1,188 @@
+/*
+Package exerrtest implements test helpers for asserting on exerr errors: fields,
+origin and stack trace of the error and the structure of the error chain.
+
+	func TestGetUser(t *testing.T) {
+		_, err := svc.GetUser(ctx, 42)
+		exerrtest.RequireField(t, err, "user_id", int64(42))
+		exerrtest.RequireOrigin(t, err, "store.(*Store).User")
+	}
+
+Functions named Require* stop the test (using t.Fatal) when the assertion fails.
+*/
+package exerrtest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+RequireField checks that the error "err" has field "name" (anywhere in the chain)
+and it's value is equal (reflect.DeepEqual) to "want".
+*/
+func RequireField(t testing.TB, err error, name string, want any) {
+	t.Helper()
+	requireError(t, err)
+	v, ok := exerr.FieldValue(err, name)
+	if !ok {
+		t.Fatalf("error %q doesn't have field %q\n%s", err, name, formatFields(exerr.Fields(err)))
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("field %q of the error %q = %s, want %s\n%s", name, err, formatValue(v), formatValue(want), formatFields(exerr.Fields(err)))
+	}
+}
+
+/*
+RequireNoField checks that the error "err" doesn't have field "name".
+*/
+func RequireNoField(t testing.TB, err error, name string) {
+	t.Helper()
+	if v, ok := exerr.FieldValue(err, name); ok {
+		t.Fatalf("error %q has unexpected field %q = %s\n%s", err, name, formatValue(v), formatFields(exerr.Fields(err)))
+	}
+}
+
+/*
+RequireOrigin checks that the error "err" was created in the function "funcName"
+(see [exerr.Origin]). Function name may be fully qualified ("example.com/pkg.Func")
+or have only the last element(s) of the package path ("pkg.Func", "pkg.(*T).Method").
+*/
+func RequireOrigin(t testing.TB, err error, funcName string) {
+	t.Helper()
+	requireError(t, err)
+	f, ok := exerr.Origin(err)
+	if !ok {
+		t.Fatalf("error %q doesn't have stack trace", err)
+	}
+	if !matchFunc(f.Function, funcName) {
+		t.Fatalf("error %q originates from %s (%s:%d), want %s", err, f.Function, f.File, f.Line, funcName)
+	}
+}
+
+/*
+RequireStackContains checks that the stack trace of the error "err" contains frame
+of the function "funcName" (see [RequireOrigin] for the format of the name).
+*/
+func RequireStackContains(t testing.TB, err error, funcName string) {
+	t.Helper()
+	requireError(t, err)
+	frames := exerr.Frames(err)
+	if slices.ContainsFunc(frames, func(f exerr.Frame) bool { return matchFunc(f.Function, funcName) }) {
+		return
+	}
+	t.Fatalf("stack trace of the error %q doesn't contain %s\n%s", err, funcName, strings.Join(exerr.Stack(err), "\n"))
+}
+
+/*
+Equal returns true when errors "a" and "b" have the same message and fields (of the
+whole chain), stack traces are ignored. Field values are compared using reflect.DeepEqual.
+*/
+func Equal(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Error() == b.Error() && reflect.DeepEqual(nonNil(exerr.Fields(a)), nonNil(exerr.Fields(b)))
+}
+
+/*
+RequireEqual checks that errors "got" and "want" are equal (see [Equal]), failure
+message contains diff of the fields.
+*/
+func RequireEqual(t testing.TB, got, want error) {
+	t.Helper()
+	if Equal(got, want) {
+		return
+	}
+	if got == nil || want == nil {
+		t.Fatalf("got error %v, want %v", got, want)
+	}
+	msg := ""
+	if got.Error() != want.Error() {
+		msg = fmt.Sprintf("error message %q, want %q\n", got, want)
+	}
+	if diff := fieldsDiff(exerr.Fields(got), exerr.Fields(want)); diff != "" {
+		msg += "fields differ (-got +want):\n" + diff
+	}
+	t.Fatal(msg)
+}
+
+func requireError(t testing.TB, err error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+/*
+matchFunc returns true when "fn" (fully qualified function name) is "name" or ends
+with "name" and the remaining prefix is package path ending with "/".
+*/
+func matchFunc(fn, name string) bool {
+	return fn == name || (strings.HasSuffix(fn, name) && strings.HasSuffix(fn[:len(fn)-len(name)], "/"))
+}
+
+func nonNil(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
+
+func formatValue(v any) string {
+	return fmt.Sprintf("%#v (%T)", v, v)
+}
+
+// formatFields returns the fields as sorted list, one field per line.
+func formatFields(fields map[string]any) string {
+	if len(fields) == 0 {
+		return "the error has no fields"
+	}
+	b := &strings.Builder{}
+	b.WriteString("fields of the error:\n")
+	for _, k := range sortedKeys(fields) {
+		fmt.Fprintf(b, "  %s: %s\n", k, formatValue(fields[k]))
+	}
+	return b.String()
+}
+
+// fieldsDiff returns lines of the fields which differ, empty string when there is no difference.
+func fieldsDiff(got, want map[string]any) string {
+	keys := sortedKeys(got)
+	for k := range want {
+		if _, ok := got[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	b := &strings.Builder{}
+	for _, k := range keys {
+		g, gok := got[k]
+		w, wok := want[k]
+		if gok && wok && reflect.DeepEqual(g, w) {
+			continue
+		}
+		if gok {
+			fmt.Fprintf(b, "- %s: %s\n", k, formatValue(g))
+		}
+		if wok {
+			fmt.Fprintf(b, "+ %s: %s\n", k, formatValue(w))
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
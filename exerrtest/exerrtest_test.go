@@ -0,0 +1,170 @@
+package exerrtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// fakeTB records the failure message of the assertion.
+type fakeTB struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Fatal(args ...any) {
+	tb.failed, tb.msg = true, fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.failed, tb.msg = true, fmt.Sprintf(format, args...)
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...any) {
+	tb.failed, tb.msg = true, fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls "assert" with fake TB and returns the failure message, empty string when the assertion passed.
+func run(t *testing.T, assert func(tb testing.TB)) string {
+	t.Helper()
+	tb := &fakeTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert(tb)
+	}()
+	<-done
+	if tb.failed && tb.msg == "" {
+		t.Fatal("assertion failed without message")
+	}
+	return tb.msg
+}
+
+func expectFailure(t *testing.T, msg string, parts ...string) {
+	t.Helper()
+	if msg == "" {
+		t.Fatal("expected assertion to fail")
+	}
+	for _, p := range parts {
+		if !strings.Contains(msg, p) {
+			t.Errorf("expected failure message to contain %q, got:\n%s", p, msg)
+		}
+	}
+}
+
+func newError() error {
+	return exerr.Errorf("order %d not found", 42).AddField("order_id", 42).AddField("user", "bob")
+}
+
+func Test_RequireField(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w", newError())
+	if msg := run(t, func(tb testing.TB) { RequireField(tb, err, "order_id", 42) }); msg != "" {
+		t.Errorf("unexpected failure: %s", msg)
+	}
+
+	msg := run(t, func(tb testing.TB) { RequireField(tb, err, "order_id", int64(42)) })
+	expectFailure(t, msg, `field "order_id" of the error "wrapped: order 42 not found" = 42 (int), want 42 (int64)`,
+		"fields of the error:\n  order_id: 42 (int)\n  user: \"bob\" (string)\n")
+
+	msg = run(t, func(tb testing.TB) { RequireField(tb, err, "missing", 1) })
+	expectFailure(t, msg, `doesn't have field "missing"`, "user: \"bob\"")
+
+	msg = run(t, func(tb testing.TB) { RequireField(tb, io.EOF, "missing", 1) })
+	expectFailure(t, msg, "the error has no fields")
+
+	msg = run(t, func(tb testing.TB) { RequireField(tb, nil, "missing", 1) })
+	expectFailure(t, msg, "expected error, got nil")
+}
+
+func Test_RequireNoField(t *testing.T) {
+	t.Parallel()
+
+	err := newError()
+	if msg := run(t, func(tb testing.TB) { RequireNoField(tb, err, "missing") }); msg != "" {
+		t.Errorf("unexpected failure: %s", msg)
+	}
+	msg := run(t, func(tb testing.TB) { RequireNoField(tb, err, "user") })
+	expectFailure(t, msg, `has unexpected field "user" = "bob" (string)`)
+}
+
+func Test_RequireOrigin(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w", newError())
+	for _, name := range []string{"exerrtest.newError", "github.com/ainvaltin/exerr/exerrtest.newError"} {
+		if msg := run(t, func(tb testing.TB) { RequireOrigin(tb, err, name) }); msg != "" {
+			t.Errorf("unexpected failure: %s", msg)
+		}
+	}
+
+	msg := run(t, func(tb testing.TB) { RequireOrigin(tb, err, "test.newError") })
+	expectFailure(t, msg, "originates from github.com/ainvaltin/exerr/exerrtest.newError (", "want test.newError")
+
+	msg = run(t, func(tb testing.TB) { RequireOrigin(tb, io.EOF, "exerrtest.newError") })
+	expectFailure(t, msg, `error "EOF" doesn't have stack trace`)
+}
+
+func Test_RequireStackContains(t *testing.T) {
+	t.Parallel()
+
+	err := newError()
+	if msg := run(t, func(tb testing.TB) { RequireStackContains(tb, err, "exerrtest.Test_RequireStackContains") }); msg != "" {
+		t.Errorf("unexpected failure: %s", msg)
+	}
+	msg := run(t, func(tb testing.TB) { RequireStackContains(tb, err, "exerrtest.nonExisting") })
+	expectFailure(t, msg, "doesn't contain exerrtest.nonExisting", "exerrtest.newError (")
+}
+
+func Test_Equal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a, b  error
+		equal bool
+	}{
+		{a: nil, b: nil, equal: true},
+		{a: io.EOF, b: nil, equal: false},
+		{a: nil, b: io.EOF, equal: false},
+		{a: io.EOF, b: errors.New("EOF"), equal: true},
+		{a: newError(), b: newError(), equal: true}, // different stacks
+		{a: exerr.New("x"), b: errors.New("x"), equal: true},
+		{a: exerr.New("x").AddField("a", 1), b: errors.New("x"), equal: false},
+		{a: exerr.New("x").AddField("a", 1), b: exerr.New("x").AddField("a", 2), equal: false},
+		{a: exerr.New("x").AddField("a", []int{1}), b: exerr.New("x").AddField("a", []int{1}), equal: true},
+		{a: exerr.New("x"), b: exerr.New("y"), equal: false},
+	}
+	for i, tc := range testCases {
+		if eq := Equal(tc.a, tc.b); eq != tc.equal {
+			t.Errorf("[%d] expected %t, got %t", i, tc.equal, eq)
+		}
+	}
+}
+
+func Test_RequireEqual(t *testing.T) {
+	t.Parallel()
+
+	if msg := run(t, func(tb testing.TB) { RequireEqual(tb, newError(), newError()) }); msg != "" {
+		t.Errorf("unexpected failure: %s", msg)
+	}
+
+	got := exerr.New("x").AddField("a", 1).AddField("b", "same").AddField("c", true)
+	want := exerr.New("y").AddField("a", 2).AddField("b", "same").AddField("d", 1.5)
+	msg := run(t, func(tb testing.TB) { RequireEqual(tb, got, want) })
+	expectFailure(t, msg, `error message "x", want "y"`,
+		"fields differ (-got +want):\n- a: 1 (int)\n+ a: 2 (int)\n- c: true (bool)\n+ d: 1.5 (float64)\n")
+
+	msg = run(t, func(tb testing.TB) { RequireEqual(tb, nil, want) })
+	expectFailure(t, msg, "got error <nil>, want y")
+}
@@ -0,0 +1,196 @@
+package exerrtest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+update is the flag for rewriting the golden files of the snapshots. The name is
+prefixed with the package name as the flag is registered in every test binary which
+imports this package and test packages commonly define "update" flag of their own.
+*/
+var update = flag.Bool("exerrtest.update", false, "update golden files of the exerrtest snapshots")
+
+type snapshotConfig struct {
+	maskLines bool
+	name      string
+}
+
+// SnapshotOption configures [Snapshot].
+type SnapshotOption func(*snapshotConfig)
+
+/*
+MaskLines replaces line numbers in the stack trace with "?" so that the snapshot
+doesn't change when unrelated code is added to the source file.
+*/
+func MaskLines() SnapshotOption {
+	return func(c *snapshotConfig) { c.maskLines = true }
+}
+
+/*
+SnapshotName sets the name of the golden file (without extension), default is the
+name of the test.
+*/
+func SnapshotName(name string) SnapshotOption {
+	return func(c *snapshotConfig) { c.name = name }
+}
+
+/*
+Snapshot renders report of the error "err" (message, template, fields and stack trace)
+and compares it with the golden file testdata/<test name>.golden. When the test is
+run with -exerrtest.update flag the golden file is (re)written instead.
+
+To make the snapshot stable the report is normalized:
+  - file paths are relative to the module root (for packages of the main module) or
+    start with the package path (for other modules), ie don't depend on the location
+    of the source code;
+  - frames of the runtime and testing packages are removed;
+  - line numbers are masked when [MaskLines] option is used.
+*/
+func Snapshot(t testing.TB, err error, opts ...SnapshotOption) {
+	t.Helper()
+	cfg := snapshotConfig{name: t.Name()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	got := render(err, cfg)
+	name := filepath.Join("testdata", sanitizeName(cfg.name)+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatalf("creating testdata directory: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, rerr := os.ReadFile(name)
+	if rerr != nil {
+		if errors.Is(rerr, fs.ErrNotExist) {
+			t.Fatalf("golden file %s doesn't exist, run the test with -exerrtest.update flag to create it", name)
+		}
+		t.Fatalf("reading golden file: %v", rerr)
+	}
+	if string(want) != got {
+		t.Errorf("error report doesn't match %s (-want +got):\n%s", name, lineDiff(string(want), got))
+	}
+}
+
+// render returns normalized text representation of the report of the error "err".
+func render(err error, cfg snapshotConfig) string {
+	if err == nil {
+		return "<nil>\n"
+	}
+	r := exerr.NewErrorReport(err)
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "message: %s\n", r.Message)
+	if r.Template != "" {
+		fmt.Fprintf(b, "template: %s\n", r.Template)
+	}
+	if len(r.Fields) != 0 {
+		b.WriteString("fields:\n")
+		for _, k := range sortedKeys(r.Fields) {
+			fmt.Fprintf(b, "  %s: %s\n", k, formatValue(r.Fields[k]))
+		}
+	}
+
+	module := ""
+	if bi := exerr.ReadBuildInfo(); bi != nil {
+		module = bi.Path
+	}
+	stack := false
+	for _, f := range r.Stack {
+		pkg := f.Package()
+		if pkg == "runtime" || pkg == "testing" {
+			continue
+		}
+		if !stack {
+			b.WriteString("stack:\n")
+			stack = true
+		}
+		line := fmt.Sprint(f.Line)
+		if cfg.maskLines {
+			line = "?"
+		}
+		fmt.Fprintf(b, "  %s (%s:%s)\n", f.Function, sourcePath(pkg, f.File, module), line)
+	}
+	return b.String()
+}
+
+/*
+sourcePath returns the path of the source "file" of the package "pkg", relative to
+the root of the "module" when the package belongs to it, otherwise the package path
+followed by the file name.
+*/
+func sourcePath(pkg, file, module string) string {
+	p := path.Join(strings.TrimSuffix(pkg, "_test"), path.Base(filepath.ToSlash(file)))
+	if module != "" && strings.HasPrefix(p, module+"/") {
+		return p[len(module)+1:]
+	}
+	return p
+}
+
+// sanitizeName turns test name into file name.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r == '/':
+			return '.'
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+/*
+lineDiff returns line based diff of "a" and "b", lines only in "a" are prefixed
+with "-", lines only in "b" with "+" and common lines with " ".
+*/
+func lineDiff(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	sb := &strings.Builder{}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			fmt.Fprintf(sb, "  %s\n", x[i])
+			i, j = i+1, j+1
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(sb, "- %s\n", x[i])
+			i++
+		default:
+			fmt.Fprintf(sb, "+ %s\n", y[j])
+			j++
+		}
+	}
+	return sb.String()
+}
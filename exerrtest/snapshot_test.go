@@ -0,0 +1,108 @@
+package exerrtest
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func loadOrder(id int) error {
+	return exerr.Wrap(fmt.Errorf("query: %w", io.EOF), "loading order").AddField("order_id", id)
+}
+
+func Test_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		Snapshot(t, nil)
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		Snapshot(t, fmt.Errorf("wrapped: %w", io.EOF))
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		Snapshot(t, fmt.Errorf("handler: %w", loadOrder(42)))
+	})
+
+	t.Run("masked lines", func(t *testing.T) {
+		Snapshot(t, exerr.Errorf("order %d not found", 42).AddField("user", "bob"), MaskLines())
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		if *update {
+			t.Skip("golden files are being updated")
+		}
+		msg := run(t, func(tb testing.TB) {
+			Snapshot(tb, exerr.New("other").AddField("user", "bob"), SnapshotName("Test_Snapshot.masked_lines"), MaskLines())
+		})
+		expectFailure(t, msg, "error report doesn't match testdata/Test_Snapshot.masked_lines.golden (-want +got):\n",
+			"- message: order 42 not found\n- template: order %d not found\n+ message: other\n  fields:\n")
+	})
+
+	t.Run("missing golden file", func(t *testing.T) {
+		if *update {
+			t.Skip("golden files are being updated")
+		}
+		msg := run(t, func(tb testing.TB) { Snapshot(tb, io.EOF, SnapshotName("non-existing")) })
+		expectFailure(t, msg, "golden file testdata/non-existing.golden doesn't exist, run the test with -exerrtest.update flag to create it")
+	})
+}
+
+func Test_render(t *testing.T) {
+	t.Parallel()
+
+	s := render(loadOrder(1), snapshotConfig{})
+	if strings.Contains(s, "runtime.") || strings.Contains(s, "testing.") {
+		t.Errorf("runtime and testing frames must be removed:\n%s", s)
+	}
+	if !strings.Contains(s, "  github.com/ainvaltin/exerr/exerrtest.loadOrder (exerrtest/snapshot_test.go:") {
+		t.Errorf("expected module relative path in:\n%s", s)
+	}
+}
+
+func Test_sourcePath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pkg, file, module string
+		path              string
+	}{
+		{pkg: "example.com/app/pkg", file: "/home/u/app/pkg/a.go", module: "example.com/app", path: "pkg/a.go"},
+		{pkg: "example.com/app/pkg_test", file: "/home/u/app/pkg/a_test.go", module: "example.com/app", path: "pkg/a_test.go"},
+		{pkg: "example.com/app", file: "example.com/app/main.go", module: "example.com/app", path: "main.go"},
+		{pkg: "example.com/application", file: "/x/y.go", module: "example.com/app", path: "example.com/application/y.go"},
+		{pkg: "net/http", file: "/usr/local/go/src/net/http/server.go", module: "example.com/app", path: "net/http/server.go"},
+		{pkg: "main", file: "/x/main.go", module: "", path: "main/main.go"},
+	}
+	for _, tc := range testCases {
+		if p := sourcePath(tc.pkg, tc.file, tc.module); p != tc.path {
+			t.Errorf("%s %s: expected %q, got %q", tc.pkg, tc.file, tc.path, p)
+		}
+	}
+}
+
+func Test_sanitizeName(t *testing.T) {
+	t.Parallel()
+
+	if s := sanitizeName("Test_X/sub test#01"); s != "Test_X.sub_test_01" {
+		t.Errorf("unexpected name %q", s)
+	}
+}
+
+func Test_lineDiff(t *testing.T) {
+	t.Parallel()
+
+	d := lineDiff("a\nb\nc", "a\nx\nc\nd")
+	if exp := "  a\n- b\n+ x\n  c\n+ d\n"; d != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, d)
+	}
+}
+
+// test packages commonly define "update" flag for their own golden files, it must
+// not conflict with the flag registered by this package
+var _ = flag.Bool("update", false, "update golden files")
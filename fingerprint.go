@@ -0,0 +1,93 @@
+package exerr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+)
+
+// type of the errors created by errors.New
+var errorStringType = reflect.TypeOf(errors.New(""))
+
+type fingerprintConfig struct {
+	lines bool
+	depth int
+}
+
+// FingerprintOption configures what is included into the fingerprint, see [Fingerprint].
+type FingerprintOption func(*fingerprintConfig)
+
+/*
+FingerprintLines includes line numbers of the stack frames into the fingerprint.
+By default only function and file names are used so that unrelated edits of the
+source file do not change the fingerprint.
+*/
+func FingerprintLines() FingerprintOption {
+	return func(fc *fingerprintConfig) { fc.lines = true }
+}
+
+/*
+FingerprintDepth limits the number of stack frames (starting from the origin of
+the error) used for the fingerprint. By default all the captured frames are used.
+*/
+func FingerprintDepth(n int) FingerprintOption {
+	return func(fc *fingerprintConfig) { fc.depth = n }
+}
+
+/*
+Fingerprint returns hash which can be used to group errors which have the same
+"cause". Input of the hash is:
+  - the stack trace of the innermost error in the chain which has it (function
+    and file name, optionally the line number);
+  - types of the errors in the chain;
+  - format strings of the errors created by [Errorf] and messages of the errors
+    created by [New] (or errors.New errors with fields attached).
+
+Error messages and field values are not used so errors which differ only by the
+arguments (ie IDs included into message) have the same fingerprint.
+
+Returns empty string for nil error.
+*/
+func Fingerprint(err error, opts ...FingerprintOption) string {
+	if err == nil {
+		return ""
+	}
+
+	cfg := fingerprintConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	h := sha256.New()
+	frames := Frames(err)
+	if cfg.depth > 0 && len(frames) > cfg.depth {
+		frames = frames[:cfg.depth]
+	}
+	for _, f := range frames {
+		fmt.Fprintf(h, "frame:%s:%s/%s", f.Function, f.Package(), path.Base(f.File))
+		if cfg.lines {
+			h.Write([]byte(":" + strconv.Itoa(f.Line)))
+		}
+		h.Write([]byte{0})
+	}
+
+	Walk(err, func(e error, depth int, _ []int) bool {
+		if ee, ok := e.(*exErr); ok {
+			template := ee.format
+			if template == "" && reflect.TypeOf(ee.err) == errorStringType {
+				// text of the error created by New is (most likely) constant
+				template = ee.err.Error()
+			}
+			fmt.Fprintf(h, "type:%d:%T\x00template:%s\x00", depth, ee.err, template)
+		} else {
+			fmt.Fprintf(h, "type:%d:%T\x00", depth, e)
+		}
+		return true
+	})
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
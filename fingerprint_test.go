@@ -0,0 +1,105 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func Test_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	newErr := func(id int) error {
+		return Errorf("order %d not found", id).AddField("id", id)
+	}
+
+	t.Run("nil error", func(t *testing.T) {
+		if fp := Fingerprint(nil); fp != "" {
+			t.Errorf("expected empty fingerprint, got %q", fp)
+		}
+	})
+
+	t.Run("same origin, different arguments", func(t *testing.T) {
+		fpA, fpB := Fingerprint(newErr(1)), Fingerprint(newErr(2))
+		if fpA == "" {
+			t.Fatal("expected non-empty fingerprint")
+		}
+		if fpA != fpB {
+			t.Errorf("expected fingerprints to be equal, got %q and %q", fpA, fpB)
+		}
+	})
+
+	t.Run("wrapping changes fingerprint", func(t *testing.T) {
+		err := newErr(1)
+		if Fingerprint(err) == Fingerprint(fmt.Errorf("wrapped: %w", err)) {
+			t.Error("expected fingerprints to be different")
+		}
+	})
+
+	t.Run("different format strings", func(t *testing.T) {
+		var errs []error
+		for _, format := range []string{"a %d", "b %d"} {
+			errs = append(errs, Errorf(format, 1))
+		}
+		if Fingerprint(errs[0]) == Fingerprint(errs[1]) {
+			t.Error("expected fingerprints to be different")
+		}
+	})
+
+	t.Run("different messages of New", func(t *testing.T) {
+		var errs []error
+		for _, msg := range []string{"not found", "permission denied"} {
+			errs = append(errs, New(msg))
+		}
+		if Fingerprint(errs[0]) == Fingerprint(errs[1]) {
+			t.Error("expected fingerprints to be different")
+		}
+		if Fingerprint(errs[0]) != Fingerprint(New("not found")) {
+			t.Error("expected fingerprints of the same message to be equal")
+		}
+	})
+
+	t.Run("different wrapped error types", func(t *testing.T) {
+		var errs []error
+		for _, e := range []error{io.EOF, &testError{}} {
+			errs = append(errs, AddField(e, "foo", 1))
+		}
+		if Fingerprint(errs[0]) == Fingerprint(errs[1]) {
+			t.Error("expected fingerprints to be different")
+		}
+	})
+
+	t.Run("stdlib errors, message is ignored", func(t *testing.T) {
+		if Fingerprint(errors.New("a")) != Fingerprint(errors.New("b")) {
+			t.Error("expected fingerprints to be equal")
+		}
+	})
+
+	t.Run("line numbers", func(t *testing.T) {
+		errA := New("some error")
+		errB := New("some error")
+		if Fingerprint(errA) != Fingerprint(errB) {
+			t.Error("expected fingerprints to be equal when line numbers are not included")
+		}
+		if Fingerprint(errA, FingerprintLines()) == Fingerprint(errB, FingerprintLines()) {
+			t.Error("expected fingerprints to be different when line numbers are included")
+		}
+	})
+
+	t.Run("depth", func(t *testing.T) {
+		// same origin, different callers
+		errA := newErr(1)
+		errB := func() error { return newErr(1) }()
+		if Fingerprint(errA) == Fingerprint(errB) {
+			t.Error("expected fingerprints to be different when all frames are used")
+		}
+		if Fingerprint(errA, FingerprintDepth(1)) != Fingerprint(errB, FingerprintDepth(1)) {
+			t.Error("expected fingerprints to be equal when only origin frame is used")
+		}
+	})
+}
+
+type testError struct{}
+
+func (testError) Error() string { return "test error" }
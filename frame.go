@@ -0,0 +1,77 @@
+package exerr
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+/*
+Frame describes single function invocation in the stack trace of the error.
+*/
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+/*
+Package returns import path of the package the function of the frame belongs to,
+ie for function "github.com/ainvaltin/exerr.(*exErr).Error" the result would be
+"github.com/ainvaltin/exerr". Empty string is returned when package can't be
+determined.
+*/
+func (f Frame) Package() string {
+	name := f.Function
+	start := strings.LastIndexByte(name, '/') + 1
+	if i := strings.IndexByte(name[start:], '.'); i >= 0 {
+		return name[:start+i]
+	}
+	return ""
+}
+
+/*
+relFile returns path of the source file relative to the root of the "module", ie
+for function "github.com/org/repo/pkg.Func" in file "/home/user/src/repo/pkg/file.go"
+and module "github.com/org/repo" the result would be "pkg/file.go".
+
+Unlike File it doesn't depend on the location of the source code on the build
+machine (or on the use of -trimpath flag), except for the main package, see [mainPackage].
+*/
+func (f Frame) relFile(module string) string {
+	pkg := f.Package()
+	if pkg == "main" {
+		pkg = mainPackage(f.File, module)
+	}
+	dir := strings.TrimPrefix(pkg, module)
+	return strings.TrimPrefix(path.Join(dir, path.Base(f.File)), "/")
+}
+
+/*
+mainPackage returns import path of the main package of the "module" whose source file
+is "file". Functions of the main package are named "main.F" so the path can only be
+derived from the file name, which is possible when the binary has been built with
+-trimpath flag (file names of the main module then start with the module path).
+Empty string is returned otherwise.
+*/
+func mainPackage(file, module string) string {
+	if module == "" || !strings.HasPrefix(file, module+"/") {
+		return ""
+	}
+	return path.Dir(file)
+}
+
+func framesOf(pcs []uintptr) (r []Frame) {
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		r = append(r, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,83 @@
+package exerr
+
+import "testing"
+
+func Test_Frame_Package(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		function string
+		pkg      string
+	}{
+		{function: "", pkg: ""},
+		{function: "main.main", pkg: "main"},
+		{function: "github.com/ainvaltin/exerr.Errorf", pkg: "github.com/ainvaltin/exerr"},
+		{function: "github.com/ainvaltin/exerr.(*exErr).Error", pkg: "github.com/ainvaltin/exerr"},
+		{function: "github.com/ainvaltin/exerr.Test_Frame.func1", pkg: "github.com/ainvaltin/exerr"},
+		{function: "example.com/foo/bar.v2/baz.Func", pkg: "example.com/foo/bar.v2/baz"},
+		{function: "net/http.(*conn).serve", pkg: "net/http"},
+	}
+
+	for _, tc := range testCases {
+		if pkg := (Frame{Function: tc.function}).Package(); pkg != tc.pkg {
+			t.Errorf("expected package of %q to be %q, got %q", tc.function, tc.pkg, pkg)
+		}
+	}
+}
+
+func Test_Frame_relFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		frame  Frame
+		module string
+		file   string
+	}{
+		{
+			frame:  Frame{Function: "github.com/org/repo/pkg.Func", File: "/home/user/src/repo/pkg/file.go"},
+			module: "github.com/org/repo",
+			file:   "pkg/file.go",
+		},
+		{
+			frame:  Frame{Function: "github.com/org/repo.Func", File: "github.com/org/repo/file.go"},
+			module: "github.com/org/repo",
+			file:   "file.go",
+		},
+		{
+			frame:  Frame{Function: "github.com/org/repo/v2/a/b.(*T).M", File: "/tmp/build/a/b/t.go"},
+			module: "github.com/org/repo/v2",
+			file:   "a/b/t.go",
+		},
+		{
+			frame:  Frame{Function: "main.main", File: "github.com/org/repo/cmd/tool/main.go"},
+			module: "github.com/org/repo",
+			file:   "cmd/tool/main.go",
+		},
+	}
+
+	for _, tc := range testCases {
+		if file := tc.frame.relFile(tc.module); file != tc.file {
+			t.Errorf("expected relative file of %v to be %q, got %q", tc.frame, tc.file, file)
+		}
+	}
+}
+
+func Test_framesOf(t *testing.T) {
+	t.Parallel()
+
+	if f := framesOf(nil); f != nil {
+		t.Errorf("expected nil for empty input, got %v", f)
+	}
+
+	err := New("some error").(*exErr)
+	frames := framesOf(err.PC())
+	if len(frames) == 0 {
+		t.Fatal("expected non-empty list of frames")
+	}
+	if fn := frames[0].Function; fn != "github.com/ainvaltin/exerr.Test_framesOf" {
+		t.Errorf("unexpected function name of the first frame: %q", fn)
+	}
+	if frames[0].Line == 0 || frames[0].File == "" {
+		t.Errorf("expected file and line to be assigned: %#v", frames[0])
+	}
+}
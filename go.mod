@@ -1,3 +1,3 @@
 module github.com/ainvaltin/exerr
 
-go 1.19
+go 1.23
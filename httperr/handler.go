@@ -0,0 +1,146 @@
+package httperr
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// Keys of the fields attached to the errors returned by the HTTP handlers.
+var (
+	Route      = exerr.NewKey[string]("http_route") // pattern of the ServeMux which matched the request
+	RemoteAddr = exerr.NewKey[string]("http_remote_addr")
+	RequestID  = exerr.NewKey[string]("request_id")
+)
+
+/*
+HandlerFunc is HTTP handler which returns error instead of writing error response
+itself, see [Middleware].
+*/
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+/*
+Middleware turns [HandlerFunc] into [http.Handler] which handles the error returned
+by the handler: request method, route, remote address and request ID are attached to
+the error as fields, the error is logged (once, with all the fields and stack trace)
+and error response is sent to the client. Panics in the handler are recovered and
+handled as errors (with the stack trace of the panic) except [http.ErrAbortHandler]
+which is re-panicked.
+
+	m := &httperr.Middleware{Logger: logger}
+	mux.Handle("GET /users/{id}", m.Handle(getUser))
+
+Error response is not sent when the handler has already written the response header
+(or flushed or hijacked the connection). The ResponseWriter passed to the handler
+implements [http.Flusher] and [http.Hijacker] (Hijack returns error when the underlying
+ResponseWriter doesn't support it) so streaming and websocket handlers keep working.
+*/
+type Middleware struct {
+	Logger *slog.Logger // when nil slog.Default() is used
+
+	// RequestID returns ID of the request, by default the value of the
+	// X-Request-Id header is used.
+	RequestID func(*http.Request) string
+
+	// WriteError writes the error response, by default response with status
+	// 500 and generic message (not revealing the error) is sent.
+	WriteError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Handle returns http.Handler which calls "h" and handles the error it returns.
+func (m *Middleware) Handle(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				m.handleError(rw, r, panicError(p))
+			}
+		}()
+
+		if err := h(rw, r); err != nil {
+			m.handleError(rw, r, err)
+		}
+	})
+}
+
+func (m *Middleware) handleError(w *responseWriter, r *http.Request, err error) {
+	e := exerr.With(err, Method, r.Method)
+	if r.Pattern != "" {
+		e = exerr.With(e, Route, r.Pattern)
+	}
+	e = exerr.With(e, RemoteAddr, r.RemoteAddr)
+	if id := m.requestID(r); id != "" {
+		e = exerr.With(e, RequestID, id)
+	}
+
+	logger := m.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(r.Context(), slog.LevelError, "request failed", exerr.LogAttrs(e)...)
+
+	if w.wroteHeader {
+		return
+	}
+	if m.WriteError != nil {
+		m.WriteError(w, r, e)
+	} else {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func (m *Middleware) requestID(r *http.Request) string {
+	if m.RequestID != nil {
+		return m.RequestID(r)
+	}
+	return r.Header.Get("X-Request-Id")
+}
+
+/*
+panicError converts value "p" recovered from panic into error. It must be called
+from the deferred func so that the stack trace includes the frames of the panic.
+*/
+func panicError(p any) error {
+	if err, ok := p.(error); ok {
+		return exerr.Errorf("panic: %w", err)
+	}
+	return exerr.Errorf("panic: %v", p)
+}
+
+// responseWriter tracks whether the response header has been written.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,270 @@
+package httperr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+type logRecord struct {
+	Level  string
+	Msg    string
+	Error  string
+	Fields map[string]any
+	Stack  []string
+}
+
+func decodeLog(t *testing.T, buf *bytes.Buffer) []logRecord {
+	t.Helper()
+	var r []logRecord
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var rec logRecord
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		r = append(r, rec)
+	}
+	return r
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	newServer := func(m *Middleware) (*httptest.Server, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		m.Logger = slog.New(slog.NewJSONHandler(buf, nil))
+		mux := http.NewServeMux()
+		mux.Handle("GET /ok", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			io.WriteString(w, "ok")
+			return nil
+		}))
+		mux.Handle("GET /users/{id}", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			return exerr.AddField(errFailed, "user_id", r.PathValue("id"))
+		}))
+		mux.Handle("GET /partial", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusAccepted)
+			return errFailed
+		}))
+		mux.Handle("GET /panic", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			var m map[string]int
+			m["boom"] = 1
+			return nil
+		}))
+		mux.Handle("GET /panic-value", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			panic("boom")
+		}))
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+		return srv, buf
+	}
+
+	get := func(t *testing.T, url string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		srv, buf := newServer(&Middleware{})
+		if code, body := get(t, srv.URL+"/ok"); code != http.StatusOK || body != "ok" {
+			t.Errorf("unexpected response %d %q", code, body)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("unexpected log output: %s", buf)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv, buf := newServer(&Middleware{})
+		code, body := get(t, srv.URL+"/users/42")
+		if code != http.StatusInternalServerError || body != "Internal Server Error\n" {
+			t.Errorf("unexpected response %d %q", code, body)
+		}
+
+		recs := decodeLog(t, buf)
+		if len(recs) != 1 {
+			t.Fatalf("expected single log record, got %d", len(recs))
+		}
+		rec := recs[0]
+		if rec.Level != "ERROR" || rec.Error != "failed" {
+			t.Errorf("unexpected log record %+v", rec)
+		}
+		exp := map[string]any{
+			"user_id":     "42",
+			"http_method": "GET",
+			"http_route":  "GET /users/{id}",
+			"request_id":  "req-1",
+		}
+		for k, v := range exp {
+			if rec.Fields[k] != v {
+				t.Errorf("expected field %s=%v, got %v", k, v, rec.Fields[k])
+			}
+		}
+		if s, _ := rec.Fields["http_remote_addr"].(string); !strings.HasPrefix(s, "127.0.0.1:") {
+			t.Errorf("unexpected remote address %q", s)
+		}
+		if len(rec.Stack) == 0 {
+			t.Error("expected stack trace to be logged")
+		}
+	})
+
+	t.Run("response already written", func(t *testing.T) {
+		srv, buf := newServer(&Middleware{})
+		if code, body := get(t, srv.URL+"/partial"); code != http.StatusAccepted || body != "" {
+			t.Errorf("unexpected response %d %q", code, body)
+		}
+		if recs := decodeLog(t, buf); len(recs) != 1 {
+			t.Errorf("expected single log record, got %d", len(recs))
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		srv, buf := newServer(&Middleware{})
+		if code, _ := get(t, srv.URL+"/panic"); code != http.StatusInternalServerError {
+			t.Errorf("unexpected status %d", code)
+		}
+		recs := decodeLog(t, buf)
+		if len(recs) != 1 {
+			t.Fatalf("expected single log record, got %d", len(recs))
+		}
+		if !strings.HasPrefix(recs[0].Error, "panic: assignment to entry in nil map") {
+			t.Errorf("unexpected error %q", recs[0].Error)
+		}
+		found := false
+		for _, s := range recs[0].Stack {
+			found = found || strings.Contains(s, "Test_Middleware")
+		}
+		if !found {
+			t.Errorf("expected stack to contain the panicking function:\n%s", strings.Join(recs[0].Stack, "\n"))
+		}
+	})
+
+	t.Run("custom request ID and response", func(t *testing.T) {
+		srv, buf := newServer(&Middleware{
+			RequestID: func(r *http.Request) string { return "custom" },
+			WriteError: func(w http.ResponseWriter, r *http.Request, err error) {
+				http.Error(w, "oops", http.StatusTeapot)
+			},
+		})
+		if code, body := get(t, srv.URL+"/panic-value"); code != http.StatusTeapot || body != "oops\n" {
+			t.Errorf("unexpected response %d %q", code, body)
+		}
+		recs := decodeLog(t, buf)
+		if len(recs) != 1 || recs[0].Error != "panic: boom" || recs[0].Fields["request_id"] != "custom" {
+			t.Errorf("unexpected log records %+v", recs)
+		}
+	})
+}
+
+func Test_Middleware_optional_interfaces(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	m := &Middleware{Logger: slog.New(slog.NewJSONHandler(buf, nil))}
+	mux := http.NewServeMux()
+	mux.Handle("GET /flush", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			return errors.New("not a Flusher")
+		}
+		io.WriteString(w, "data: 1\n\n")
+		f.Flush()
+		return errors.New("stream failed")
+	}))
+	mux.Handle("GET /hijack", m.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			return errors.New("not a Hijacker")
+		}
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		rw.Flush()
+		return errors.New("connection failed")
+	}))
+	// the client gets the response before the error is logged so wait for the handler to return
+	done := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		done <- struct{}{}
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		path string
+		code int
+		body string
+		err  string
+	}{
+		{path: "/flush", code: http.StatusOK, body: "data: 1\n\n", err: "stream failed"},
+		{path: "/hijack", code: http.StatusNoContent, body: "", err: "connection failed"},
+	}
+	for _, tc := range testCases {
+		buf.Reset()
+		resp, err := http.Get(srv.URL + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		<-done
+		if resp.StatusCode != tc.code || string(b) != tc.body {
+			t.Errorf("%s: unexpected response %d %q", tc.path, resp.StatusCode, b)
+		}
+		if recs := decodeLog(t, buf); len(recs) != 1 || recs[0].Error != tc.err {
+			t.Errorf("%s: unexpected log records %+v", tc.path, recs)
+		}
+	}
+
+	t.Run("hijacking not supported", func(t *testing.T) {
+		rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+		if _, _, err := rw.Hijack(); err == nil {
+			t.Error("expected error")
+		}
+		if rw.wroteHeader {
+			t.Error("failed hijack must not mark header as written")
+		}
+	})
+}
+
+func Test_Middleware_abort(t *testing.T) {
+	t.Parallel()
+
+	m := &Middleware{Logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+	h := m.Handle(func(w http.ResponseWriter, r *http.Request) error { panic(http.ErrAbortHandler) })
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("expected ErrAbortHandler to be re-panicked, got %v", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func Test_panicError(t *testing.T) {
+	t.Parallel()
+
+	err := panicError(io.EOF)
+	if !errors.Is(err, io.EOF) || err.Error() != "panic: EOF" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Package httperr integrates exerr with the net/http package.
+*/
+package httperr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// Keys of the fields attached to the errors of the HTTP client requests.
+var (
+	Method  = exerr.NewKey[string]("http_method")
+	URL     = exerr.NewKey[string]("http_url") // query values are redacted
+	Attempt = exerr.NewKey[int]("http_attempt")
+	Status  = exerr.NewKey[int]("http_status")
+	Body    = exerr.NewKey[string]("http_body") // beginning of the response body
+)
+
+const defaultSnippetSize = 512
+
+/*
+Transport is [http.RoundTripper] which returns errors of the "Base" round tripper as
+exerr errors with request method, URL and attempt number (see [WithAttempt]) attached
+as fields.
+
+When FailStatus policy is set responses for which it returns true are turned into
+errors ([StatusError] with the response status and the beginning of the response body
+attached as fields) and the response is discarded. Note that this violates the contract
+of the [http.RoundTripper] so when the Transport is used by code which expects to see
+all the responses the policy shouldn't be set.
+
+	client := &http.Client{Transport: &httperr.Transport{FailStatus: httperr.Non2xx}}
+*/
+type Transport struct {
+	Base http.RoundTripper // when nil http.DefaultTransport is used
+
+	// FailStatus decides whether the response should be returned as error.
+	FailStatus func(*http.Response) bool
+
+	// SnippetSize is max number of bytes of the response body attached to the
+	// error, zero means default (512), negative value disables attaching the body.
+	SnippetSize int
+}
+
+// Non2xx is FailStatus policy which turns all responses with non 2xx status code into errors.
+func Non2xx(resp *http.Response) bool {
+	return resp.StatusCode < 200 || resp.StatusCode > 299
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, &roundTripError{err: t.annotate(err, req), base: err}
+	}
+	if t.FailStatus == nil || !t.FailStatus(resp) {
+		return resp, nil
+	}
+
+	e := t.annotate(&StatusError{StatusCode: resp.StatusCode, Status: resp.Status}, req)
+	e = exerr.With(e, Status, resp.StatusCode)
+	if n := t.snippetSize(); n > 0 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, int64(n)))
+		if len(b) != 0 {
+			e = exerr.With(e, Body, strings.ToValidUTF8(string(b), "�"))
+		}
+	}
+	resp.Body.Close()
+	return nil, e
+}
+
+func (t *Transport) annotate(err error, req *http.Request) exerr.ErrorWithFields {
+	e := exerr.With(err, Method, req.Method)
+	e = exerr.With(e, URL, redactURL(req.URL))
+	if n := AttemptFrom(req.Context()); n > 0 {
+		e = exerr.With(e, Attempt, n)
+	}
+	return e
+}
+
+func (t *Transport) snippetSize() int {
+	if t.SnippetSize == 0 {
+		return defaultSnippetSize
+	}
+	return t.SnippetSize
+}
+
+/*
+roundTripError is the annotated error of the base round tripper. It forwards Timeout
+and Temporary methods of the original error as [url.Error] (which the http.Client
+returns) checks does the error it wraps implement them, without forwarding the
+timeouts would be hidden by the exerr error.
+*/
+type roundTripError struct {
+	err  error // annotated error
+	base error // error returned by the base round tripper
+}
+
+func (e *roundTripError) Error() string { return e.err.Error() }
+
+func (e *roundTripError) Unwrap() error { return e.err }
+
+func (e *roundTripError) Timeout() bool {
+	t, ok := e.base.(interface{ Timeout() bool })
+	return ok && t.Timeout()
+}
+
+func (e *roundTripError) Temporary() bool {
+	t, ok := e.base.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+/*
+StatusError is returned by the [Transport] for responses which the FailStatus policy
+decided to be errors.
+*/
+type StatusError struct {
+	StatusCode int
+	Status     string // ie "404 Not Found"
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %s", e.Status)
+}
+
+/*
+redactURL returns "u" as string with user password, values of the query parameters
+and query parameters without value replaced with "REDACTED" and fragment removed.
+*/
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	r := *u
+	r.Fragment, r.RawFragment = "", ""
+	if r.RawQuery != "" {
+		params := strings.Split(r.RawQuery, "&")
+		for i, p := range params {
+			if k, _, ok := strings.Cut(p, "="); ok {
+				params[i] = k + "=REDACTED"
+			} else if p != "" {
+				// parameter without value might be a secret token itself
+				params[i] = "REDACTED"
+			}
+		}
+		r.RawQuery = strings.Join(params, "&")
+	}
+	return r.Redacted()
+}
+
+type attemptKey struct{}
+
+/*
+WithAttempt returns context which carries number of the attempt of the request (when
+requests are retried), the [Transport] attaches it to the errors.
+*/
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// AttemptFrom returns the attempt number stored in the context, zero when not set.
+func AttemptFrom(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
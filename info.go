@@ -1,9 +1,10 @@
 package exerr
 
 import (
-	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
+	"strings"
 )
 
 /*
@@ -15,15 +16,14 @@ To check does only this particular err have the field check does it implement
 method and if it does call it.
 */
 func FieldValue(err error, name string) (value any, ok bool) {
-	for ; err != nil; err = errors.Unwrap(err) {
-		if fv, ok := err.(interface{ FieldValue(name string) (any, bool) }); ok {
-			if v, ok := fv.FieldValue(name); ok {
-				return v, true
-			}
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if fv, isFV := e.(interface{ FieldValue(name string) (any, bool) }); isFV {
+			value, ok = fv.FieldValue(name)
 		}
-	}
+		return !ok
+	})
 
-	return nil, false
+	return value, ok
 }
 
 /*
@@ -38,8 +38,8 @@ method and if it does call it.
 */
 func Fields(err error) map[string]any {
 	var f map[string]any
-	for ; err != nil; err = errors.Unwrap(err) {
-		if fv, ok := err.(interface{ Fields() map[string]any }); ok {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if fv, ok := e.(interface{ Fields() map[string]any }); ok {
 			if f == nil {
 				f = make(map[string]any)
 			}
@@ -47,37 +47,140 @@ func Fields(err error) map[string]any {
 				f[k] = v
 			}
 		}
-	}
+		return true
+	})
 
 	return f
 }
 
+/*
+Template returns the format string and non-error arguments of the outermost error in
+the chain which has been created by [Errorf]. Errors passed as arguments to Errorf are
+not returned as they are part of the error chain.
+
+Template is useful for structured logging where the message template (as opposed to
+the rendered message) is used to group log records. Returns empty string and nil when
+no error in the chain has the template.
+
+To check does this particular err have the template check does it implement
+
+	Template() (string, []any)
+
+method and if it does call it.
+*/
+func Template(err error) (format string, args []any) {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if t, ok := e.(interface{ Template() (string, []any) }); ok {
+			format, args = t.Template()
+		}
+		return format == ""
+	})
+	return format, args
+}
+
 type stacked interface {
 	PC() []uintptr
 }
 
+/*
+Stack returns the stack trace of the innermost error in the chain which has it, formatted
+as "function (file:line)" strings. To get structured information use [Frames].
+*/
 func Stack(err error) []string {
-	var se stacked
-	for ; err != nil; err = errors.Unwrap(err) {
-		if s, ok := err.(stacked); ok {
-			se = s
-		}
+	frames := Frames(err)
+	if frames == nil {
+		return nil
+	}
+	r := make([]string, 0, len(frames))
+	for _, f := range frames {
+		r = append(r, fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
 	}
+	return r
+}
 
-	if se != nil {
-		return formatStack(se.PC())
+/*
+Frames returns the stack trace of the innermost error in the chain which has it.
+*/
+func Frames(err error) []Frame {
+	return framesOf(stackPC(err))
+}
+
+/*
+OwnFrames returns the stack trace carried by "err" itself, unlike [Frames] it doesn't
+look into the errors "err" wraps. Returns nil when "err" doesn't have stack trace.
+*/
+func OwnFrames(err error) []Frame {
+	if err == nil {
+		return nil
 	}
-	return nil
+	pcs, _ := stackOf(err)
+	return framesOf(pcs)
 }
 
-func formatStack(pcs []uintptr) (r []string) {
+/*
+Origin returns the location where the innermost error in the chain which has stack
+trace was created. Frames of the exerr package itself are skipped. For errors created
+by the integration packages (sqlerr, httperr) frames of these and the standard library
+packages calling them (ie database/sql, net/http) are skipped too so that the location
+is in the code which made the call. Note that in [CaptureOriginOnly] mode the errors
+created by the integration packages have no origin.
+
+As the result has low cardinality it is suitable for use as a metrics label.
+*/
+func Origin(err error) (Frame, bool) {
+	pcs := stackPC(err)
+	if len(pcs) == 0 {
+		return Frame{}, false
+	}
 	frames := runtime.CallersFrames(pcs)
+	inIntegration := false
 	for {
 		frame, more := frames.Next()
-		r = append(r, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		f := Frame{Function: frame.Function, File: frame.File, Line: frame.Line}
+		switch {
+		case isExerrFrame(f):
+			inIntegration = inIntegration || f.Package() != pkgPath
+		case inIntegration && isStdlibFrame(f):
+		default:
+			return f, true
+		}
 		if !more {
-			break
+			return Frame{}, false
 		}
 	}
-	return r
+}
+
+var pkgPath = reflect.TypeOf(exErr{}).PkgPath()
+
+// isExerrFrame returns true when "f" is in the (non-test) source of this package or it's sub-packages.
+func isExerrFrame(f Frame) bool {
+	return hasPathPrefix(f.Package(), pkgPath) && !strings.HasSuffix(f.File, "_test.go")
+}
+
+/*
+isStdlibFrame returns true when "f" is (most likely) in the standard library, ie the
+first element of the package path doesn't contain dot and the package is not part of
+the main module.
+*/
+func isStdlibFrame(f Frame) bool {
+	pkg := f.Package()
+	if pkg == "" || pkg == "main" {
+		return false
+	}
+	if bi := ReadBuildInfo(); bi != nil && bi.Path != "" && hasPathPrefix(pkg, bi.Path) {
+		return false
+	}
+	elem, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(elem, ".")
+}
+
+// stackPC returns program counters of the innermost error in the chain which has them.
+func stackPC(err error) (pcs []uintptr) {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if s, ok := stackOf(e); ok {
+			pcs = s
+		}
+		return true
+	})
+	return pcs
 }
@@ -1,7 +1,11 @@
 package exerr
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -179,3 +183,220 @@ func Test_Fields(t *testing.T) {
 		}
 	})
 }
+
+func Test_OwnFrames(t *testing.T) {
+	t.Parallel()
+
+	if f := OwnFrames(nil); f != nil {
+		t.Errorf("expected no frames for nil error, got %v", f)
+	}
+	if f := OwnFrames(fmt.Errorf("some error")); f != nil {
+		t.Errorf("expected no frames for stdlib error, got %v", f)
+	}
+
+	inner := Errorf("inner")
+	outer := Errorf("outer: %w", inner)
+	wrapped := fmt.Errorf("wrapped: %w", outer)
+	if f := OwnFrames(wrapped); f != nil {
+		t.Errorf("expected no frames for wrapping stdlib error, got %v", f)
+	}
+	outerFrames, innerFrames := OwnFrames(outer), OwnFrames(inner)
+	if len(outerFrames) == 0 || len(innerFrames) == 0 {
+		t.Fatal("expected frames to be returned")
+	}
+	if outerFrames[0] == innerFrames[0] {
+		t.Errorf("expected own frames of the outer error, got %v", outerFrames[0])
+	}
+}
+
+func Test_Frames(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stdlib error", func(t *testing.T) {
+		if f := Frames(fmt.Errorf("some error")); f != nil {
+			t.Errorf("expected no frames, got %v", f)
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		if f := Frames(nil); f != nil {
+			t.Errorf("expected no frames, got %v", f)
+		}
+	})
+
+	t.Run("innermost stack is returned", func(t *testing.T) {
+		inner := Errorf("inner")
+		err := Errorf("outer: %w", inner)
+
+		frames := Frames(err)
+		if len(frames) == 0 {
+			t.Fatal("expected frames to be returned")
+		}
+		innerFrames := framesOf(inner.(*exErr).PC())
+		if frames[0] != innerFrames[0] {
+			t.Errorf("expected first frame to be\n%v\ngot\n%v", innerFrames[0], frames[0])
+		}
+
+		stack := Stack(err)
+		if len(stack) != len(frames) {
+			t.Fatalf("expected Stack to return %d items, got %d", len(frames), len(stack))
+		}
+		if exp := fmt.Sprintf("%s (%s:%d)", frames[0].Function, frames[0].File, frames[0].Line); stack[0] != exp {
+			t.Errorf("expected first line of the stack to be %q, got %q", exp, stack[0])
+		}
+	})
+}
+
+func Test_Template(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if f, a := Template(nil); f != "" || a != nil {
+			t.Errorf("expected no template, got %q %v", f, a)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		if f, a := Template(fmt.Errorf("id %d", 1)); f != "" || a != nil {
+			t.Errorf("expected no template, got %q %v", f, a)
+		}
+	})
+
+	t.Run("New", func(t *testing.T) {
+		if f, a := Template(New("some error")); f != "" || a != nil {
+			t.Errorf("expected no template, got %q %v", f, a)
+		}
+	})
+
+	t.Run("Errorf without arguments", func(t *testing.T) {
+		f, a := Template(Errorf("some error"))
+		if f != "some error" || a != nil {
+			t.Errorf("unexpected template %q %v", f, a)
+		}
+	})
+
+	t.Run("Errorf with arguments", func(t *testing.T) {
+		inner := io.EOF
+		err := Errorf("reading %s (offset %d): %w", "foo.txt", 42, inner)
+		if msg := err.Error(); msg != "reading foo.txt (offset 42): EOF" {
+			t.Errorf("unexpected error message %q", msg)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Error("expected wrapped error to be detected by errors.Is")
+		}
+
+		f, a := Template(err)
+		if f != "reading %s (offset %d): %w" {
+			t.Errorf("unexpected format %q", f)
+		}
+		if len(a) != 2 || a[0] != "foo.txt" || a[1] != 42 {
+			t.Errorf("unexpected arguments %v", a)
+		}
+	})
+
+	t.Run("outermost template is returned", func(t *testing.T) {
+		err := fmt.Errorf("std: %w", Errorf("outer %d: %w", 1, Errorf("inner %d", 2)))
+		f, a := Template(err)
+		if f != "outer %d: %w" || len(a) != 1 || a[0] != 1 {
+			t.Errorf("unexpected template %q %v", f, a)
+		}
+	})
+}
+
+func Test_Origin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if f, ok := Origin(nil); ok {
+			t.Errorf("unexpectedly origin was found: %v", f)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		if f, ok := Origin(fmt.Errorf("some error")); ok {
+			t.Errorf("unexpectedly origin was found: %v", f)
+		}
+	})
+
+	t.Run("innermost error", func(t *testing.T) {
+		inner := New("inner")
+		err := Errorf("outer: %w", inner)
+		f, ok := Origin(err)
+		if !ok {
+			t.Fatal("expected origin to be found")
+		}
+		if exp := framesOf(inner.(*exErr).PC())[0]; f != exp {
+			t.Errorf("expected origin\n%v\ngot\n%v", exp, f)
+		}
+		if f.Function != "github.com/ainvaltin/exerr.Test_Origin.func3" {
+			t.Errorf("unexpected function %q", f.Function)
+		}
+	})
+
+	t.Run("frames of exerr are skipped", func(t *testing.T) {
+		// fake stack where the error is created inside New (PCs are return
+		// addresses so add one to the function entry)
+		pcs := []uintptr{reflect.ValueOf(New).Pointer() + 1, 0}
+		runtime.Callers(1, pcs[1:])
+		frames := framesOf(pcs)
+		if frames[0].Function != "github.com/ainvaltin/exerr.New" {
+			t.Fatalf("unexpected function of the first frame %q", frames[0].Function)
+		}
+
+		f, ok := Origin(&exErr{pcs: pcs})
+		if !ok {
+			t.Fatal("expected origin to be found")
+		}
+		if f != frames[1] {
+			t.Errorf("expected origin\n%v\ngot\n%v", frames[1], f)
+		}
+		if f.Function != "github.com/ainvaltin/exerr.Test_Origin.func4" {
+			t.Errorf("unexpected function %q", f.Function)
+		}
+	})
+}
+
+func Test_isExerrFrame(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		frame Frame
+		exerr bool
+	}{
+		{frame: Frame{Function: "github.com/ainvaltin/exerr.Errorf", File: "/src/exerr/api.go"}, exerr: true},
+		{frame: Frame{Function: "github.com/ainvaltin/exerr.(*exErr).Error", File: "/src/exerr/exerr.go"}, exerr: true},
+		{frame: Frame{Function: "github.com/ainvaltin/exerr.Test_Origin", File: "/src/exerr/info_test.go"}, exerr: false},
+		{frame: Frame{Function: "github.com/ainvaltin/exerr/sqlerr.(*conn).Exec", File: "/src/exerr/sqlerr/conn.go"}, exerr: true},
+		{frame: Frame{Function: "github.com/ainvaltin/exerr/sqlerr.Test_Origin", File: "/src/exerr/sqlerr/sqlerr_test.go"}, exerr: false},
+		{frame: Frame{Function: "github.com/ainvaltin/exerrfoo.F", File: "/src/exerrfoo/foo.go"}, exerr: false},
+		{frame: Frame{Function: "main.main", File: "/src/app/main.go"}, exerr: false},
+	}
+
+	for _, tc := range testCases {
+		if r := isExerrFrame(tc.frame); r != tc.exerr {
+			t.Errorf("expected %t for %v, got %t", tc.exerr, tc.frame, r)
+		}
+	}
+}
+
+func Test_isStdlibFrame(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		frame  Frame
+		stdlib bool
+	}{
+		{frame: Frame{Function: "database/sql.(*DB).execDC", File: "/usr/local/go/src/database/sql/sql.go"}, stdlib: true},
+		{frame: Frame{Function: "net/http.(*Client).do", File: "net/http/client.go"}, stdlib: true},
+		{frame: Frame{Function: "runtime.goexit", File: "/usr/local/go/src/runtime/asm_amd64.s"}, stdlib: true},
+		{frame: Frame{Function: "main.main", File: "/src/app/main.go"}, stdlib: false},
+		{frame: Frame{Function: "github.com/org/app.F", File: "/src/app/app.go"}, stdlib: false},
+		{frame: Frame{Function: "github.com/ainvaltin/exerr.Test_Origin", File: "/src/exerr/info_test.go"}, stdlib: false},
+	}
+
+	for _, tc := range testCases {
+		if r := isStdlibFrame(tc.frame); r != tc.stdlib {
+			t.Errorf("expected %t for %v, got %t", tc.stdlib, tc.frame, r)
+		}
+	}
+}
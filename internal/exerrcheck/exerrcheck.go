@@ -0,0 +1,368 @@
+/*
+Package exerrcheck implements static analysis pass which reports misuse of the
+exerr package:
+  - exerr.New or exerr.Errorf used to create sentinel error (in package level
+    variable initializer);
+  - field name which is not constant or not in snake_case;
+  - exerr.AddField (or exerr.With) called with error which is (possibly) nil;
+  - the same field name added more than once in a chain of calls.
+*/
+package exerrcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/ainvaltin/exerr/internal/srcload"
+)
+
+const exerrPath = "github.com/ainvaltin/exerr"
+
+// Diagnostic is a problem found by the pass.
+type Diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+/*
+Run checks the package "pkg" and returns diagnostics sorted by position. The exerr
+package itself is not checked.
+*/
+func Run(pkg *srcload.Package) []Diagnostic {
+	if pkg.Path == exerrPath {
+		return nil
+	}
+	c := &checker{info: pkg.Info}
+	for _, f := range pkg.Files {
+		c.checkFile(f)
+	}
+	sort.SliceStable(c.diags, func(i, j int) bool { return c.diags[i].Pos < c.diags[j].Pos })
+	return c.diags
+}
+
+type checker struct {
+	info     *types.Info
+	assigned map[*ast.Ident]bool
+	diags    []Diagnostic
+}
+
+func (c *checker) report(pos token.Pos, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// names of the exerr funcs and methods (as returned by types.Func.FullName)
+const (
+	fnNew          = exerrPath + ".New"
+	fnErrorf       = exerrPath + ".Errorf"
+	fnAddField     = exerrPath + ".AddField"
+	fnWith         = exerrPath + ".With"
+	fnWithFields   = exerrPath + ".WithFields"
+	fnNewKey       = exerrPath + ".NewKey"
+	fnScope        = exerrPath + ".Scope"
+	fnAnnotate     = exerrPath + ".Annotate"
+	methodAddField = "(" + exerrPath + ".ErrorWithFields).AddField"
+	methodWith     = "(*" + exerrPath + ".Builder).With"
+)
+
+/*
+fieldNameArgs describes which arguments of the exerr funcs are field names: "name"
+is index of the single name argument, "kv" is index where the list of alternating
+names and values starts (-1 when not applicable).
+*/
+var fieldNameArgs = map[string]struct{ name, kv int }{
+	fnAddField:     {name: 1, kv: -1},
+	fnNewKey:       {name: 0, kv: -1},
+	methodAddField: {name: 0, kv: -1},
+	methodWith:     {name: 0, kv: -1},
+	fnWithFields:   {name: -1, kv: 1},
+	fnScope:        {name: -1, kv: 0},
+	fnAnnotate:     {name: -1, kv: 1},
+}
+
+func (c *checker) checkFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+			c.checkSentinels(gd)
+		}
+	}
+
+	c.collectAssigned(f)
+	nilChecks := c.collectNilChecks(f)
+	inChain := make(map[*ast.CallExpr]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name := c.calleeName(call)
+		if name == "" {
+			return true
+		}
+		c.checkFieldNames(call, name)
+		if name == fnAddField || name == fnWith {
+			c.checkNilError(call, name, nilChecks)
+		}
+		if !inChain[call] {
+			c.checkDuplicateFields(call, inChain)
+		}
+		return true
+	})
+}
+
+/*
+calleeName returns full name of the exerr func or method called by "call", empty
+string when the callee is not part of the exerr package.
+*/
+func (c *checker) calleeName(call *ast.CallExpr) string {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return ""
+	}
+	fn, ok := c.info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != exerrPath {
+		return ""
+	}
+	return fn.Origin().FullName()
+}
+
+/*
+checkSentinels reports exerr constructors used in package level variable initializers.
+Function literals are not inspected as their body is not executed at initialization.
+*/
+func (c *checker) checkSentinels(gd *ast.GenDecl) {
+	for _, spec := range gd.Specs {
+		for _, v := range spec.(*ast.ValueSpec).Values {
+			ast.Inspect(v, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					return false
+				case *ast.CallExpr:
+					switch c.calleeName(n) {
+					case fnNew:
+						c.report(n.Pos(), "exerr.New should not be used to create sentinel errors, use errors.New instead")
+					case fnErrorf:
+						c.report(n.Pos(), "exerr.Errorf should not be used to create sentinel errors, use fmt.Errorf instead")
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+func (c *checker) checkFieldNames(call *ast.CallExpr, callee string) {
+	args, ok := fieldNameArgs[callee]
+	if !ok {
+		return
+	}
+	if args.name >= 0 && args.name < len(call.Args) {
+		c.checkFieldName(call.Args[args.name])
+	}
+	if args.kv >= 0 && !call.Ellipsis.IsValid() {
+		for i := args.kv; i < len(call.Args); i += 2 {
+			c.checkFieldName(call.Args[i])
+		}
+	}
+}
+
+func (c *checker) checkFieldName(expr ast.Expr) {
+	name, ok := c.constString(expr)
+	if !ok {
+		c.report(expr.Pos(), "field name should be constant")
+		return
+	}
+	if !snakeCase.MatchString(name) {
+		c.report(expr.Pos(), "field name %q is not in snake_case", name)
+	}
+}
+
+func (c *checker) constString(expr ast.Expr) (string, bool) {
+	tv, ok := c.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// nilCheck is the block in which the variable is known to be nil.
+type nilCheck struct {
+	obj        types.Object
+	start, end token.Pos
+}
+
+/*
+collectNilChecks finds blocks where variable is known to be nil, ie the body of
+"if err == nil" and the else block of "if err != nil".
+*/
+func (c *checker) collectNilChecks(f *ast.File) (r []nilCheck) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		ifs, ok := n.(*ast.IfStmt)
+		if !ok {
+			return true
+		}
+		be, ok := ast.Unparen(ifs.Cond).(*ast.BinaryExpr)
+		if !ok || (be.Op != token.EQL && be.Op != token.NEQ) {
+			return true
+		}
+		x, y := ast.Unparen(be.X), ast.Unparen(be.Y)
+		if c.isNil(x) {
+			x, y = y, x
+		}
+		id, ok := x.(*ast.Ident)
+		if !ok || !c.isNil(y) || c.info.Uses[id] == nil {
+			return true
+		}
+		var block ast.Node = ifs.Body
+		if be.Op == token.NEQ {
+			if ifs.Else == nil {
+				return true
+			}
+			block = ifs.Else
+		}
+		r = append(r, nilCheck{obj: c.info.Uses[id], start: block.Pos(), end: block.End()})
+		return true
+	})
+	return r
+}
+
+func (c *checker) isNil(expr ast.Expr) bool {
+	tv, ok := c.info.Types[expr]
+	return ok && tv.IsNil()
+}
+
+func (c *checker) checkNilError(call *ast.CallExpr, name string, nilChecks []nilCheck) {
+	if len(call.Args) == 0 {
+		return
+	}
+	fn := path.Base(name) // ie "exerr.AddField"
+	arg := ast.Unparen(call.Args[0])
+	if c.isNil(arg) {
+		c.report(arg.Pos(), "nil error passed to %s creates error with message \"<nil>\", use exerr.WithFields instead", fn)
+		return
+	}
+	id, ok := arg.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := c.info.Uses[id]
+	for _, nc := range nilChecks {
+		if nc.obj == obj && nc.start <= call.Pos() && call.End() <= nc.end && !c.assignedBetween(obj, nc.start, call.Pos()) {
+			c.report(arg.Pos(), "error %s is nil here, %s creates error with message \"<nil>\", use exerr.WithFields instead", id.Name, fn)
+			return
+		}
+	}
+}
+
+// assignedBetween returns true when "obj" might be assigned a value between positions "start" and "end".
+func (c *checker) assignedBetween(obj types.Object, start, end token.Pos) bool {
+	for id := range c.assigned {
+		if start <= id.Pos() && id.Pos() < end && c.info.Uses[id] == obj {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+collectAssigned finds identifiers which are on the left side of assignment or whose
+address is taken (and thus might be assigned).
+*/
+func (c *checker) collectAssigned(f *ast.File) {
+	c.assigned = make(map[*ast.Ident]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if id, ok := ast.Unparen(lhs).(*ast.Ident); ok {
+					c.assigned[id] = true
+				}
+			}
+		case *ast.UnaryExpr:
+			if id, ok := ast.Unparen(n.X).(*ast.Ident); ok && n.Op == token.AND {
+				c.assigned[id] = true
+			}
+		}
+		return true
+	})
+}
+
+type fieldName struct {
+	name string
+	pos  token.Pos
+}
+
+/*
+checkDuplicateFields reports field names which are added more than once in the
+chain of calls ending with "call". All the calls of the chain are marked in the
+"inChain" so that the sub-chains are not checked again.
+*/
+func (c *checker) checkDuplicateFields(call *ast.CallExpr, inChain map[*ast.CallExpr]bool) {
+	seen := make(map[string]bool)
+	for _, fn := range c.chainFields(call, inChain) {
+		if seen[fn.name] {
+			c.report(fn.pos, "field %q is already added to the error", fn.name)
+		}
+		seen[fn.name] = true
+	}
+}
+
+/*
+chainFields returns constant field names added by the chain of calls ending with "call",
+in the order they are added.
+*/
+func (c *checker) chainFields(call *ast.CallExpr, inChain map[*ast.CallExpr]bool) (r []fieldName) {
+	var inner ast.Expr
+	switch c.calleeName(call) {
+	case methodAddField:
+		inner = call.Fun.(*ast.SelectorExpr).X
+		r = c.appendName(r, call.Args, 0)
+	case fnAddField:
+		inner = call.Args[0]
+		r = c.appendName(r, call.Args, 1)
+	case fnWithFields:
+		inner = call.Args[0]
+		if !call.Ellipsis.IsValid() {
+			for i := 1; i < len(call.Args); i += 2 {
+				r = c.appendName(r, call.Args, i)
+			}
+		}
+	default:
+		return nil
+	}
+	inChain[call] = true
+
+	if ic, ok := ast.Unparen(inner).(*ast.CallExpr); ok {
+		r = append(c.chainFields(ic, inChain), r...)
+	}
+	return r
+}
+
+func (c *checker) appendName(names []fieldName, args []ast.Expr, idx int) []fieldName {
+	if idx < len(args) {
+		if name, ok := c.constString(args[idx]); ok {
+			names = append(names, fieldName{name: name, pos: args[idx].Pos()})
+		}
+	}
+	return names
+}
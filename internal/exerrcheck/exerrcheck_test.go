@@ -0,0 +1,106 @@
+package exerrcheck
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr/internal/srcload"
+)
+
+func Test_Run(t *testing.T) {
+	runTest(t, "a")
+}
+
+type expectation struct {
+	line    int
+	re      *regexp.Regexp
+	matched bool
+}
+
+/*
+runTest type-checks package testdata/src/<pkg>, runs the pass on it and compares
+the diagnostics with the expectations in "// want" comments (like analysistest).
+*/
+func runTest(t *testing.T, pkg string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "src", pkg, "*.go"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no files found for package %s: %v", pkg, err)
+	}
+
+	fset := token.NewFileSet()
+	var wants []*expectation
+	imports := map[string]bool{}
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, is := range f.Imports {
+			path, _ := strconv.Unquote(is.Path.Value)
+			imports[path] = true
+		}
+		wants = append(wants, parseWants(t, fset, f)...)
+	}
+
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	imp, err := srcload.Importer(fset, ".", paths...)
+	if err != nil {
+		t.Fatalf("creating importer: %v", err)
+	}
+	p, err := srcload.Check(fset, imp, pkg, files)
+	if err != nil {
+		t.Fatalf("loading package: %v", err)
+	}
+
+	for _, d := range Run(p) {
+		pos := fset.Position(d.Pos)
+		found := false
+		for _, w := range wants {
+			if w.line == pos.Line && !w.matched && w.re.MatchString(d.Message) {
+				w.matched, found = true, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: unexpected diagnostic: %s", pos, d.Message)
+		}
+	}
+	for _, w := range wants {
+		if !w.matched {
+			t.Errorf("line %d: expected diagnostic matching %q", w.line, w.re)
+		}
+	}
+}
+
+var wantRE = regexp.MustCompile("`[^`]*`|\"(?:[^\"\\\\]|\\\\.)*\"")
+
+func parseWants(t *testing.T, fset *token.FileSet, f *ast.File) (r []*expectation) {
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			text, ok := strings.CutPrefix(c.Text, "// want ")
+			if !ok {
+				continue
+			}
+			line := fset.Position(c.Pos()).Line
+			for _, q := range wantRE.FindAllString(text, -1) {
+				s, err := strconv.Unquote(q)
+				if err != nil {
+					t.Fatalf("line %d: invalid want pattern %s: %v", line, q, err)
+				}
+				r = append(r, &expectation{line: line, re: regexp.MustCompile(s)})
+			}
+		}
+	}
+	return r
+}
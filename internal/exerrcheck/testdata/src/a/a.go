@@ -0,0 +1,84 @@
+package a
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// sentinel errors
+
+var ErrOK = errors.New("ok")
+
+var ErrNew = exerr.New("not ok") // want `exerr.New should not be used to create sentinel errors`
+
+var (
+	ErrErrorf = exerr.Errorf("not ok: %w", ErrOK)          // want `exerr.Errorf should not be used to create sentinel errors`
+	ErrNested = fmt.Errorf("wrap: %w", exerr.New("inner")) // want `exerr.New should not be used`
+	newErr    = func() error { return exerr.New("created later") }
+)
+
+// field names
+
+const fieldName = "const_name"
+
+type key string
+
+func fieldNames(err error, name string, k key) {
+	_ = exerr.AddField(err, "user_id", 1)
+	_ = exerr.AddField(err, fieldName, 1)
+	_ = exerr.AddField(err, name, 1)                        // want `field name should be constant`
+	_ = exerr.AddField(err, "userID", 1)                    // want `field name "userID" is not in snake_case`
+	_ = exerr.AddField(err, string(k), 1)                   // want `field name should be constant`
+	_ = exerr.New("x").AddField("Bad Name", 1)              // want `field name "Bad Name" is not in snake_case`
+	_ = exerr.WithFields(err, "ok", 1, "notOk", 2, name, 3) // want `field name "notOk" is not in snake_case` `field name should be constant`
+	_ = exerr.Scope("op", "x", "_bad", 1)                   // want `field name "_bad" is not in snake_case`
+	_ = exerr.Scope().With("camelCase", 1)                  // want `field name "camelCase" is not in snake_case`
+	_ = exerr.NewKey[int]("keyName")                        // want `field name "keyName" is not in snake_case`
+
+	kv := []any{"a", 1}
+	_ = exerr.WithFields(err, kv...)
+}
+
+func annotate() (err error) {
+	defer exerr.Annotate(&err, "op", "annotate", "Id", 1) // want `field name "Id" is not in snake_case`
+	return nil
+}
+
+// nil errors
+
+var key1 = exerr.NewKey[int]("key1")
+
+func nilErrors(err error) error {
+	_ = exerr.AddField(nil, "a", 1) // want `nil error passed to exerr.AddField`
+	_ = exerr.With(nil, key1, 1)    // want `nil error passed to exerr.With`
+
+	if err == nil {
+		return exerr.AddField(err, "a", 1) // want `error err is nil here`
+	}
+	if err != nil {
+		_ = exerr.AddField(err, "a", 1)
+	} else {
+		_ = exerr.AddField(err, "a", 1) // want `error err is nil here, exerr.AddField creates`
+		_ = exerr.With(err, key1, 1)    // want `error err is nil here, exerr.With creates`
+	}
+	if err == nil {
+		err = errors.New("reassigned")
+		return exerr.AddField(err, "a", 1)
+	}
+	return exerr.WithFields(nil, "a", 1)
+}
+
+// duplicate fields
+
+func duplicates(err error) error {
+	_ = exerr.New("x").AddField("a", 1).AddField("b", 2).AddField("a", 3) // want `field "a" is already added to the error`
+	_ = exerr.AddField(err, "a", 1).AddField("a", 2)                      // want `field "a" is already added to the error`
+	_ = exerr.WithFields(err, "a", 1, "b", 2, "b", 3)                     // want `field "b" is already added to the error`
+	_ = exerr.AddField(exerr.WithFields(err, "a", 1), "a", 2)             // want `field "a" is already added to the error`
+	_ = exerr.AddField(err, "a", 1).AddField("b", 2)
+
+	e := exerr.AddField(err, "a", 1)
+	return e.AddField("a", 2) // not reported, not a single chain
+}
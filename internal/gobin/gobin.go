@@ -0,0 +1,129 @@
+/*
+Package gobin implements reading of the symbol information from Go executables.
+Only ELF binaries are supported.
+*/
+package gobin
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"errors"
+	"fmt"
+)
+
+// File is an opened Go executable.
+type File struct {
+	BuildID string
+	Table   *gosym.Table
+}
+
+/*
+Open reads build ID and function table of the executable "name".
+*/
+func Open(name string) (*File, error) {
+	ef, err := elf.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening ELF file: %w", err)
+	}
+	defer ef.Close()
+
+	f := &File{}
+	if f.BuildID, err = buildID(ef); err != nil {
+		return nil, fmt.Errorf("reading build ID: %w", err)
+	}
+	if f.Table, err = symTable(ef); err != nil {
+		return nil, fmt.Errorf("reading function table: %w", err)
+	}
+	return f, nil
+}
+
+/*
+buildID returns Go build ID stored in the ".note.go.buildid" section.
+*/
+func buildID(ef *elf.File) (string, error) {
+	sect := ef.Section(".note.go.buildid")
+	if sect == nil {
+		return "", errors.New("binary doesn't have Go build ID note")
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return "", err
+	}
+	// note: namesz, descsz, type (4 bytes each), name (padded to 4 bytes), desc
+	if len(data) < 16 {
+		return "", errors.New("invalid build ID note")
+	}
+	nameSz := ef.ByteOrder.Uint32(data[0:])
+	descSz := ef.ByteOrder.Uint32(data[4:])
+	name := data[12:]
+	if nameSz != 4 || !bytes.HasPrefix(name, []byte("Go\x00\x00")) {
+		return "", errors.New("build ID note has unexpected name")
+	}
+	desc := name[4:]
+	if uint32(len(desc)) < descSz {
+		return "", errors.New("build ID note is truncated")
+	}
+	return string(desc[:descSz]), nil
+}
+
+/*
+symTable creates function table out of the pclntab of the binary. The pclntab is
+in it's own section for executables but inside ".data.rel.ro" in case of PIE.
+*/
+func symTable(ef *elf.File) (*gosym.Table, error) {
+	text := ef.Section(".text")
+	if text == nil {
+		return nil, errors.New("binary doesn't have .text section")
+	}
+
+	var pclntab []byte
+	if sect := ef.Section(".gopclntab"); sect != nil {
+		data, err := sect.Data()
+		if err != nil {
+			return nil, err
+		}
+		pclntab = data
+	} else {
+		data, err := symbolData(ef, "runtime.pclntab", "runtime.epclntab")
+		if err != nil {
+			return nil, err
+		}
+		pclntab = data
+	}
+
+	return gosym.NewTable(nil, gosym.NewLineTable(pclntab, text.Addr))
+}
+
+/*
+symbolData returns content of the binary between addresses of symbols "start" and "end".
+*/
+func symbolData(ef *elf.File, start, end string) ([]byte, error) {
+	syms, err := ef.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	var startAddr, endAddr uint64
+	for _, s := range syms {
+		switch s.Name {
+		case start:
+			startAddr = s.Value
+		case end:
+			endAddr = s.Value
+		}
+	}
+	if startAddr == 0 || endAddr <= startAddr {
+		return nil, fmt.Errorf("symbols %s and %s not found", start, end)
+	}
+
+	for _, prog := range ef.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Vaddr <= startAddr && endAddr <= prog.Vaddr+prog.Filesz {
+			data := make([]byte, endAddr-startAddr)
+			if _, err := prog.ReadAt(data, int64(startAddr-prog.Vaddr)); err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("segment containing %s not found", start)
+}
@@ -0,0 +1,53 @@
+package gobin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_Open(t *testing.T) {
+	t.Run("test binary", func(t *testing.T) {
+		exe, err := os.Executable()
+		if err != nil {
+			t.Fatalf("locating test binary: %v", err)
+		}
+		f, err := Open(exe)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if f.BuildID == "" {
+			t.Error("expected build ID to be assigned")
+		}
+
+		fn := runtime.FuncForPC(reflect.ValueOf(Open).Pointer())
+		sym := f.Table.LookupFunc(fn.Name())
+		if sym == nil {
+			t.Fatalf("function %q not found", fn.Name())
+		}
+		// offset is non-zero when the test binary is PIE
+		offset := uint64(fn.Entry()) - sym.Entry
+		file, line := fn.FileLine(fn.Entry())
+		if f, l, _ := f.Table.PCToLine(uint64(fn.Entry()) - offset); f != file || l != line {
+			t.Errorf("expected %s:%d got %s:%d", file, line, f, l)
+		}
+	})
+
+	t.Run("not ELF file", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "foo")
+		if err := os.WriteFile(name, []byte("not ELF"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(name); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("file doesn't exist", func(t *testing.T) {
+		if _, err := Open(filepath.Join(t.TempDir(), "foo")); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
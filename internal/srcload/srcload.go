@@ -0,0 +1,157 @@
+/*
+Package srcload loads and type-checks Go packages using only the standard library
+and the go command (a minimal substitute for golang.org/x/tools/go/packages).
+*/
+package srcload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Package is parsed and type-checked Go package.
+type Package struct {
+	Path  string // import path
+	Dir   string
+	Fset  *token.FileSet
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+}
+
+type listPackage struct {
+	ImportPath string
+	Dir        string
+	Export     string
+	GoFiles    []string
+	DepOnly    bool
+	Error      *struct{ Err string }
+}
+
+/*
+Load loads packages matching "patterns" (as understood by "go list") in the directory
+"dir". Only non-test Go files of the packages are loaded.
+*/
+func Load(dir string, patterns ...string) ([]*Package, error) {
+	list, err := goList(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	imp := newImporter(fset, list)
+	var pkgs []*Package
+	for _, lp := range list {
+		if lp.DepOnly {
+			continue
+		}
+		files := make([]string, 0, len(lp.GoFiles))
+		for _, name := range lp.GoFiles {
+			files = append(files, filepath.Join(lp.Dir, name))
+		}
+		pkg, err := Check(fset, imp, lp.ImportPath, files)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Dir = lp.Dir
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+/*
+Importer returns importer for packages "paths" (and their dependencies), to be used
+with [Check].
+*/
+func Importer(fset *token.FileSet, dir string, paths ...string) (types.Importer, error) {
+	list, err := goList(dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	return newImporter(fset, list), nil
+}
+
+// newImporter returns importer which reads export data of the packages in "list".
+func newImporter(fset *token.FileSet, list []*listPackage) types.Importer {
+	exports := make(map[string]string, len(list))
+	for _, lp := range list {
+		exports[lp.ImportPath] = lp.Export
+	}
+	return importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		name, ok := exports[path]
+		if !ok || name == "" {
+			return nil, fmt.Errorf("no export data for %q", path)
+		}
+		return os.Open(name)
+	})
+}
+
+// goList returns packages matching "patterns" and all their dependencies.
+func goList(dir string, patterns []string) ([]*listPackage, error) {
+	args := append([]string{"list", "-e", "-json=ImportPath,Dir,Export,GoFiles,DepOnly,Error", "-export", "-deps", "--"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, stderr.String())
+	}
+
+	var list []*listPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		lp := &listPackage{}
+		if err := dec.Decode(lp); err != nil {
+			if errors.Is(err, io.EOF) {
+				return list, nil
+			}
+			return nil, fmt.Errorf("decoding go list output: %w", err)
+		}
+		if lp.Error != nil {
+			return nil, fmt.Errorf("package %s: %s", lp.ImportPath, lp.Error.Err)
+		}
+		list = append(list, lp)
+	}
+}
+
+/*
+Check parses and type-checks package "path" consisting of "files".
+*/
+func Check(fset *token.FileSet, imp types.Importer, path string, files []string) (*Package, error) {
+	pkg := &Package{
+		Path: path,
+		Fset: fset,
+		Info: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		},
+	}
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Files = append(pkg.Files, f)
+	}
+
+	conf := types.Config{Importer: imp}
+	var err error
+	if pkg.Types, err = conf.Check(path, fset, pkg.Files, pkg.Info); err != nil {
+		return nil, fmt.Errorf("type-checking %s: %w", path, err)
+	}
+	return pkg, nil
+}
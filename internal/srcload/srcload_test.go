@@ -0,0 +1,55 @@
+package srcload
+
+import (
+	"go/token"
+	"testing"
+)
+
+func Test_Load(t *testing.T) {
+	t.Run("package of the module", func(t *testing.T) {
+		pkgs, err := Load("../..", ".")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pkgs) != 1 {
+			t.Fatalf("expected one package, got %d", len(pkgs))
+		}
+		pkg := pkgs[0]
+		if pkg.Path != "github.com/ainvaltin/exerr" || pkg.Types.Name() != "exerr" {
+			t.Errorf("unexpected package %s (%s)", pkg.Path, pkg.Types.Name())
+		}
+		if len(pkg.Files) == 0 || pkg.Dir == "" {
+			t.Errorf("expected files and dir to be assigned")
+		}
+		if obj := pkg.Types.Scope().Lookup("Errorf"); obj == nil {
+			t.Error("expected Errorf to be found in the package scope")
+		}
+		if len(pkg.Info.Uses) == 0 {
+			t.Error("expected type info to be recorded")
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := Load(".", "./nonexisting"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func Test_Importer(t *testing.T) {
+	fset := token.NewFileSet()
+	imp, err := Importer(fset, ".", "fmt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pkg, err := imp.Import("fmt")
+	if err != nil {
+		t.Fatalf("importing fmt: %v", err)
+	}
+	if pkg.Scope().Lookup("Errorf") == nil {
+		t.Error("expected Errorf to be found in fmt")
+	}
+	if _, err := imp.Import("net/http"); err == nil {
+		t.Error("expected error for package which wasn't listed")
+	}
+}
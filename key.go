@@ -0,0 +1,54 @@
+package exerr
+
+/*
+Key is a typed name of the field. Using keys instead of string names makes field
+access type safe and typos in field names compile time errors:
+
+	var UserID = exerr.NewKey[int64]("user_id")
+
+	err := exerr.With(exerr.New("not allowed"), UserID, 42)
+	...
+	if uid, ok := UserID.From(err); ok {
+
+Fields added using keys are ordinary fields, ie they are returned by [Fields] and
+[FieldValue] under the name of the key.
+*/
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns key for the field "name" whose value is of type T.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name of the field.
+func (k Key[T]) Name() string { return k.name }
+
+/*
+From returns the first value of the field in the error chain. The ok is false when
+the field is not found or it's value is not of type T.
+*/
+func (k Key[T]) From(err error) (value T, ok bool) {
+	if v, found := FieldValue(err, k.name); found {
+		value, ok = v.(T)
+	}
+	return value, ok
+}
+
+/*
+With attaches field "key" with "value" to the "err", it is typed version of [AddField]
+(including the semantics when "err" is nil).
+*/
+func With[T any](err error, key Key[T], value T) ErrorWithFields {
+	if isNil(err) {
+		if strictNil.Load() {
+			return nil
+		}
+		err = nil
+	}
+	if af, ok := err.(ErrorWithFields); ok {
+		return af.AddField(key.name, value)
+	}
+	return newExErr(err).AddField(key.name, value)
+}
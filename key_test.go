@@ -0,0 +1,84 @@
+package exerr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_Key(t *testing.T) {
+	t.Parallel()
+
+	userID := NewKey[int64]("user_id")
+	name := NewKey[string]("name")
+
+	if n := userID.Name(); n != "user_id" {
+		t.Errorf("unexpected key name %q", n)
+	}
+
+	t.Run("field not found", func(t *testing.T) {
+		v, ok := userID.From(fmt.Errorf("some error"))
+		if ok || v != 0 {
+			t.Errorf("unexpectedly field was found: %v", v)
+		}
+
+		v, ok = userID.From(nil)
+		if ok || v != 0 {
+			t.Errorf("unexpectedly field was found: %v", v)
+		}
+	})
+
+	t.Run("field exists", func(t *testing.T) {
+		err := With(New("some error"), userID, 42)
+		if v, ok := userID.From(err); !ok || v != 42 {
+			t.Errorf("expected field value 42, got %v (found %t)", v, ok)
+		}
+		if n := errChainLen(err); n != 1 {
+			t.Errorf("expected chain length 1, got %d", n)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		origErr := fmt.Errorf("some error")
+		err := With(With(origErr, userID, 42), name, "foo")
+		if v, ok := userID.From(err); !ok || v != 42 {
+			t.Errorf("expected field value 42, got %v (found %t)", v, ok)
+		}
+		if v, ok := name.From(err); !ok || v != "foo" {
+			t.Errorf("expected field value %q, got %q (found %t)", "foo", v, ok)
+		}
+		if n := errChainLen(err); n != 1 {
+			t.Errorf("expected chain length 1, got %d", n)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Key.func3" {
+			t.Errorf("expected stack to be captured at the call site, origin is %v", f)
+		}
+	})
+
+	t.Run("interoperability with string names", func(t *testing.T) {
+		err := With(Errorf("some error"), userID, 42).AddField("other", 1)
+
+		flds := Fields(err)
+		containsField(t, flds, "user_id", int64(42))
+		containsField(t, flds, "other", 1)
+		expectFieldValue(t, err, "user_id", int64(42))
+
+		err = AddField(err, "user_id", int64(7))
+		if v, ok := userID.From(err); !ok || v != 7 {
+			t.Errorf("expected field value 7, got %v (found %t)", v, ok)
+		}
+	})
+
+	t.Run("value of wrong type", func(t *testing.T) {
+		err := AddField(New("some error"), "user_id", "not int")
+		if v, ok := userID.From(err); ok {
+			t.Errorf("unexpectedly value of wrong type was returned: %v", v)
+		}
+	})
+
+	t.Run("wrapped error", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", With(New("some error"), userID, 1))
+		if v, ok := userID.From(err); !ok || v != 1 {
+			t.Errorf("expected field value 1, got %v (found %t)", v, ok)
+		}
+	})
+}
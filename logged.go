@@ -0,0 +1,47 @@
+package exerr
+
+/*
+MarkLogged records that the error "err" has been logged so that code up in the call
+chain can avoid logging it again (see [IsLogged] and [HandlerOptions]). The mark is
+stored on the outermost exerr error in the chain of "err" so it survives wrapping
+by other errors. When the chain doesn't contain exerr error "err" is wrapped into one
+(no new stack trace is captured), the returned error must be used instead of "err":
+
+	logger.Error("failed to send email", "err", err)
+	return exerr.MarkLogged(err)
+
+Returns nil when "err" is nil.
+*/
+func MarkLogged(err error) error {
+	if isNil(err) {
+		return nil
+	}
+	marked := false
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if ee, ok := e.(*exErr); ok && ee != nil {
+			ee.logged.Store(true)
+			marked = true
+		}
+		return !marked
+	})
+	if marked {
+		return err
+	}
+	e := &exErr{err: err, pcs: stackPC(err)}
+	e.logged.Store(true)
+	return e
+}
+
+/*
+IsLogged returns true when any error in the chain of "err" has been marked as logged
+using [MarkLogged].
+*/
+func IsLogged(err error) (logged bool) {
+	Walk(err, func(e error, _ int, _ []int) bool {
+		if ee, ok := e.(*exErr); ok && ee != nil && ee.logged.Load() {
+			logged = true
+		}
+		return !logged
+	})
+	return logged
+}
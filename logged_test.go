@@ -0,0 +1,79 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func Test_MarkLogged(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if err := MarkLogged(nil); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		if err := MarkLogged((*exErr)(nil)); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		if IsLogged(nil) {
+			t.Error("nil error is not logged")
+		}
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		err := New("failed")
+		if IsLogged(err) {
+			t.Error("new error is not logged")
+		}
+		if e := MarkLogged(err); e != err {
+			t.Error("expected the same error to be returned")
+		}
+		if !IsLogged(err) {
+			t.Error("expected error to be logged")
+		}
+	})
+
+	t.Run("survives wrapping", func(t *testing.T) {
+		err := MarkLogged(New("failed"))
+		for _, e := range []error{
+			fmt.Errorf("wrapped: %w", err),
+			Wrap(err, "wrapped"),
+			errors.Join(io.EOF, fmt.Errorf("wrapped: %w", err)),
+		} {
+			if !IsLogged(e) {
+				t.Errorf("expected %q to be logged", e)
+			}
+		}
+	})
+
+	t.Run("mark is stored on outermost exerr error", func(t *testing.T) {
+		inner := New("inner")
+		outer := Wrap(inner, "outer")
+		MarkLogged(fmt.Errorf("wrapped: %w", outer))
+		if IsLogged(inner) {
+			t.Error("inner error must not be marked")
+		}
+		if !IsLogged(outer) {
+			t.Error("expected outer error to be marked")
+		}
+	})
+
+	t.Run("chain without exerr error", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", io.EOF)
+		e := MarkLogged(err)
+		if !IsLogged(e) {
+			t.Error("expected returned error to be logged")
+		}
+		if IsLogged(err) {
+			t.Error("original error can't be marked")
+		}
+		if e.Error() != err.Error() || !errors.Is(e, io.EOF) {
+			t.Errorf("expected transparent wrapper, got %v", e)
+		}
+		if Frames(e) != nil {
+			t.Error("no stack trace should be captured")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package exerr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+KeyValue is attribute describing the error, in the form which is easy to convert into
+OpenTelemetry attribute (attribute.KeyValue) without this package depending on the
+OpenTelemetry API. Value is one of bool, int64, float64, string or slice of these.
+*/
+type KeyValue struct {
+	Key   string
+	Value any
+}
+
+// Names of the attributes defined by the OpenTelemetry exception semantic conventions.
+const (
+	OTelExceptionType       = "exception.type"
+	OTelExceptionMessage    = "exception.message"
+	OTelExceptionStacktrace = "exception.stacktrace"
+)
+
+type otelConfig struct {
+	prefix string
+}
+
+// OTelOption configures [OTelAttributes].
+type OTelOption func(*otelConfig)
+
+/*
+OTelFieldPrefix sets the prefix of the attribute names of the fields, default is
+"exerr.field.", ie field "user_id" becomes attribute "exerr.field.user_id".
+*/
+func OTelFieldPrefix(prefix string) OTelOption {
+	return func(c *otelConfig) { c.prefix = prefix }
+}
+
+/*
+OTelAttributes returns attributes describing error "err" following the OpenTelemetry
+exception semantic conventions (exception.type, exception.message and, when the error
+has stack trace, exception.stacktrace) followed by the fields of the error (see
+[Fields]) sorted by name. Returns nil for nil error.
+
+Example of recording the error as span event:
+
+	attrs := exerr.OTelAttributes(err)
+	kv := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kv = append(kv, attribute.String(a.Key, v))
+		...
+		}
+	}
+	span.AddEvent("exception", trace.WithAttributes(kv...))
+*/
+func OTelAttributes(err error, opts ...OTelOption) []KeyValue {
+	if isNil(err) {
+		return nil
+	}
+	cfg := otelConfig{prefix: "exerr.field."}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	attrs := []KeyValue{
+		{Key: OTelExceptionType, Value: typeName(err)},
+		{Key: OTelExceptionMessage, Value: err.Error()},
+	}
+	if frames := Frames(err); len(frames) != 0 {
+		b := &strings.Builder{}
+		for _, f := range frames {
+			fmt.Fprintf(b, "%s(...)\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		attrs = append(attrs, KeyValue{Key: OTelExceptionStacktrace, Value: b.String()})
+	}
+
+	fields := Fields(err)
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	for _, k := range names {
+		attrs = append(attrs, KeyValue{Key: cfg.prefix + k, Value: otelValue(fields[k])})
+	}
+	return attrs
+}
+
+/*
+typeName returns name of the type of the error "err" (as printed by %T verb), errors
+created by this package are reported as "exerr.Error".
+*/
+func typeName(err error) string {
+	if _, ok := err.(*exErr); ok {
+		return "exerr.Error"
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+/*
+otelValue converts "v" into type supported by OpenTelemetry attributes, values of
+unsupported types are converted to string.
+*/
+func otelValue(v any) any {
+	switch v := v.(type) {
+	case bool, int64, float64, string, []bool, []int64, []float64, []string:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []int:
+		r := make([]int64, len(v))
+		for i, x := range v {
+			r[i] = int64(x)
+		}
+		return r
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
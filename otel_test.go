@@ -0,0 +1,94 @@
+package exerr
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+func Test_OTelAttributes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if attrs := OTelAttributes(nil); attrs != nil {
+			t.Errorf("expected nil, got %v", attrs)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		attrs := OTelAttributes(fmt.Errorf("wrapped: %w", io.EOF))
+		exp := []KeyValue{{Key: "exception.type", Value: "*fmt.wrapError"}, {Key: "exception.message", Value: "wrapped: EOF"}}
+		if fmt.Sprint(attrs) != fmt.Sprint(exp) {
+			t.Errorf("expected %v, got %v", exp, attrs)
+		}
+	})
+
+	t.Run("stacktrace", func(t *testing.T) {
+		err := New("failed")
+		attrs := OTelAttributes(err)
+		if len(attrs) != 3 || attrs[2].Key != OTelExceptionStacktrace {
+			t.Fatalf("unexpected attributes %v", attrs)
+		}
+		if attrs[0].Value != "exerr.Error" {
+			t.Errorf("unexpected exception type %v", attrs[0].Value)
+		}
+		lines := strings.Split(attrs[2].Value.(string), "\n")
+		if f := Frames(err)[0]; lines[0] != f.Function+"(...)" || lines[1] != fmt.Sprintf("\t%s:%d", f.File, f.Line) {
+			t.Errorf("unexpected stack trace:\n%s", attrs[2].Value)
+		}
+	})
+
+	t.Run("golden", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", Errorf("order %d not found", 42).
+			AddField("bool", true).
+			AddField("int", 1).
+			AddField("uint8", uint8(2)).
+			AddField("float32", float32(0.5)).
+			AddField("string", "s").
+			AddField("ints", []int{1, 2}).
+			AddField("strings", []string{"a", "b"}).
+			AddField("duration", time.Second).
+			AddField("error", errors.New("inner")).
+			AddField("struct", struct{ A int }{A: 1}).
+			AddField("nil", nil))
+
+		buf := &bytes.Buffer{}
+		for _, opts := range [][]OTelOption{nil, {OTelFieldPrefix("app.")}} {
+			for _, a := range OTelAttributes(err, opts...) {
+				v := a.Value
+				if a.Key == OTelExceptionStacktrace {
+					v = "<stack trace>"
+				}
+				fmt.Fprintf(buf, "%s = (%T) %#v\n", a.Key, v, v)
+			}
+			buf.WriteString("\n")
+		}
+
+		name := filepath.Join("testdata", "otel_attributes.golden")
+		if *update {
+			if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(exp, buf.Bytes()) {
+			t.Errorf("output doesn't match %s:\n%s", name, buf)
+		}
+	})
+}
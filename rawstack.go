@@ -0,0 +1,63 @@
+package exerr
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/ainvaltin/exerr/internal/gobin"
+)
+
+/*
+RawStack is the stack trace of the error as program counters, to be symbolized
+later (ie using the exerr-symbolize tool) with the help of the binary which created
+the error.
+
+PCs are the raw values as returned by [runtime.Callers], to get the addresses in
+the binary LoadOffset has to be subtracted from them.
+*/
+type RawStack struct {
+	BuildID    string    `json:"build_id,omitempty"`
+	LoadOffset uint64    `json:"load_offset,omitempty"`
+	PCs        []uintptr `json:"pcs"`
+}
+
+var (
+	exeBuildID    string
+	exeLoadOffset uint64
+	exeInfoOnce   sync.Once
+)
+
+/*
+readExeInfo reads build ID of the running binary and calculates the difference
+between the address of the function in the binary and in the memory (which is
+non-zero for position independent executables).
+
+Failure to read the info is not fatal, the stack could still be symbolized when
+the binary is known.
+*/
+func readExeInfo() {
+	name, err := os.Executable()
+	if err != nil {
+		return
+	}
+	f, err := gobin.Open(name)
+	if err != nil {
+		return
+	}
+	exeBuildID = f.BuildID
+
+	fn := runtime.FuncForPC(reflect.ValueOf(readExeInfo).Pointer())
+	if sym := f.Table.LookupFunc(fn.Name()); sym != nil {
+		exeLoadOffset = uint64(fn.Entry()) - sym.Entry
+	}
+}
+
+func newRawStack(pcs []uintptr) *RawStack {
+	if len(pcs) == 0 {
+		return nil
+	}
+	exeInfoOnce.Do(readExeInfo)
+	return &RawStack{BuildID: exeBuildID, LoadOffset: exeLoadOffset, PCs: pcs}
+}
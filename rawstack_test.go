@@ -0,0 +1,35 @@
+package exerr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_WithRawStack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error without stack", func(t *testing.T) {
+		r := NewErrorReport(fmt.Errorf("some error"), WithRawStack())
+		if r.Raw != nil || r.Stack != nil {
+			t.Errorf("expected no stack in the report, got %#v", r)
+		}
+	})
+
+	t.Run("error with stack", func(t *testing.T) {
+		err := Errorf("some error")
+		r := NewErrorReport(err, WithRawStack())
+		if r.Stack != nil {
+			t.Errorf("expected no symbolized stack in the report, got %v", r.Stack)
+		}
+		if r.Raw == nil {
+			t.Fatal("expected raw stack to be included")
+		}
+		if r.Raw.BuildID == "" {
+			t.Error("expected build ID to be assigned")
+		}
+		pcs := err.(*exErr).PC()
+		if len(r.Raw.PCs) != len(pcs) || r.Raw.PCs[0] != pcs[0] {
+			t.Errorf("expected PCs %v got %v", pcs, r.Raw.PCs)
+		}
+	})
+}
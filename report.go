@@ -0,0 +1,101 @@
+package exerr
+
+/*
+ErrorReport is a snapshot of the information available about the error, suitable
+for serializing (ie as JSON) and sending to log or error tracking system.
+*/
+type ErrorReport struct {
+	Message  string         `json:"message"`
+	Template string         `json:"message_template,omitempty"` // see Template
+	Fields   map[string]any `json:"fields,omitempty"`
+	Stack    []ReportFrame  `json:"stack,omitempty"`
+	Raw      *RawStack      `json:"raw_stack,omitempty"` // see WithRawStack
+	Build    *BuildInfo     `json:"build,omitempty"`
+
+	Fingerprint string `json:"fingerprint,omitempty"` // see WithFingerprint
+}
+
+// ReportFrame is stack frame of the ErrorReport.
+type ReportFrame struct {
+	Frame
+	Link string `json:"link,omitempty"` // URL of the source code, see WithSourceLinks
+}
+
+type reportConfig struct {
+	buildInfo   bool
+	fingerprint bool
+	rawStack    bool
+	links       SourceLinks
+}
+
+// ReportOption configures the content of the ErrorReport, see [NewErrorReport].
+type ReportOption func(*reportConfig)
+
+/*
+WithBuildInfo includes build information (main module version, VCS revision, Go
+version) into the report.
+*/
+func WithBuildInfo() ReportOption {
+	return func(rc *reportConfig) { rc.buildInfo = true }
+}
+
+/*
+WithSourceLinks adds link to the source code to the stack frames of the modules which
+have URL template in the "links" and whose revision is known (see [SourceLinks]).
+*/
+func WithSourceLinks(links SourceLinks) ReportOption {
+	return func(rc *reportConfig) { rc.links = links }
+}
+
+/*
+WithRawStack makes the report to contain unsymbolized stack trace (see [RawStack])
+instead of the list of frames. This makes creating the report considerably cheaper,
+symbolization can be done offline when (if) the report is needed.
+
+The exerr-symbolize tool can be used to replace raw stacks in JSON logs with frames.
+*/
+func WithRawStack() ReportOption {
+	return func(rc *reportConfig) { rc.rawStack = true }
+}
+
+/*
+WithFingerprint includes [Fingerprint] of the error (with default options) into the report.
+*/
+func WithFingerprint() ReportOption {
+	return func(rc *reportConfig) { rc.fingerprint = true }
+}
+
+/*
+NewErrorReport collects information about the error "err" into ErrorReport.
+Returns nil when "err" is nil.
+*/
+func NewErrorReport(err error, opts ...ReportOption) *ErrorReport {
+	if err == nil {
+		return nil
+	}
+
+	cfg := reportConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	r := &ErrorReport{
+		Message: err.Error(),
+		Fields:  Fields(err),
+	}
+	r.Template, _ = Template(err)
+	if cfg.buildInfo {
+		r.Build = ReadBuildInfo()
+	}
+	if cfg.fingerprint {
+		r.Fingerprint = Fingerprint(err)
+	}
+	if cfg.rawStack {
+		r.Raw = newRawStack(stackPC(err))
+		return r
+	}
+	for _, f := range Frames(err) {
+		r.Stack = append(r.Stack, ReportFrame{Frame: f, Link: cfg.links.link(ReadBuildInfo(), f)})
+	}
+	return r
+}
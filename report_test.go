@@ -0,0 +1,81 @@
+package exerr
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func Test_NewErrorReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if r := NewErrorReport(nil); r != nil {
+			t.Errorf("expected nil report, got %#v", r)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		r := NewErrorReport(fmt.Errorf("some error"))
+		if r.Message != "some error" {
+			t.Errorf("unexpected message %q", r.Message)
+		}
+		if r.Fields != nil || r.Stack != nil || r.Build != nil {
+			t.Errorf("expected report to contain only message, got %#v", r)
+		}
+	})
+
+	t.Run("exerr with fields", func(t *testing.T) {
+		r := NewErrorReport(fmt.Errorf("wrapped: %w", Errorf("some %s", "error").AddField("foo", 42)))
+		if r.Message != "wrapped: some error" {
+			t.Errorf("unexpected message %q", r.Message)
+		}
+		if r.Template != "some %s" {
+			t.Errorf("unexpected message template %q", r.Template)
+		}
+		containsField(t, r.Fields, "foo", 42)
+		if len(r.Stack) == 0 {
+			t.Fatal("expected stack to be included")
+		}
+		if fn := r.Stack[0].Function; fn != "github.com/ainvaltin/exerr.Test_NewErrorReport.func3" {
+			t.Errorf("unexpected function of the first frame %q", fn)
+		}
+		if r.Build != nil {
+			t.Error("build info was not requested but is included")
+		}
+	})
+
+	t.Run("with build info", func(t *testing.T) {
+		r := NewErrorReport(New("some error"), WithBuildInfo())
+		if r.Build == nil || r.Build != ReadBuildInfo() {
+			t.Errorf("expected build info to be included, got %#v", r.Build)
+		}
+	})
+
+	t.Run("with fingerprint", func(t *testing.T) {
+		err := New("some error")
+		if r := NewErrorReport(err); r.Fingerprint != "" {
+			t.Errorf("fingerprint was not requested but is included: %q", r.Fingerprint)
+		}
+		if r := NewErrorReport(err, WithFingerprint()); r.Fingerprint != Fingerprint(err) {
+			t.Errorf("expected fingerprint %q, got %q", Fingerprint(err), r.Fingerprint)
+		}
+	})
+
+	t.Run("JSON encoding", func(t *testing.T) {
+		r := &ErrorReport{
+			Message: "some error",
+			Fields:  map[string]any{"foo": 42},
+			Stack:   []ReportFrame{{Frame: Frame{Function: "main.main", File: "main.go", Line: 5}, Link: "https://example.com/main.go#L5"}},
+			Build:   &BuildInfo{Path: "example.com/app", Revision: "abc", GoVersion: "go1.21.0"},
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("encoding report: %v", err)
+		}
+		exp := `{"message":"some error","fields":{"foo":42},"stack":[{"function":"main.main","file":"main.go","line":5,"link":"https://example.com/main.go#L5"}],"build":{"path":"example.com/app","vcs_revision":"abc","go_version":"go1.21.0"}}`
+		if s := string(b); s != exp {
+			t.Errorf("expected\n%s\ngot\n%s", exp, s)
+		}
+	})
+}
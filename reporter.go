@@ -0,0 +1,52 @@
+package exerr
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+/*
+Reporter sends errors to error tracking system, see [Report] and [SetReporter].
+The exerr/reporter package contains implementation which sends reports asynchronously
+to various backends.
+*/
+type Reporter interface {
+	Report(ctx context.Context, err error)
+}
+
+type reporterHolder struct{ r Reporter }
+
+var reporter atomic.Pointer[reporterHolder]
+
+/*
+SetReporter sets the Reporter which receives errors passed to [Report], nil disables
+reporting. Usually called once during program startup.
+*/
+func SetReporter(r Reporter) {
+	if r == nil {
+		reporter.Store(nil)
+		return
+	}
+	reporter.Store(&reporterHolder{r: r})
+}
+
+/*
+Report sends error "err" to the Reporter set with [SetReporter]. Does nothing when
+"err" is nil or Reporter has not been set.
+
+The Reporter must not block the caller so Report is safe to call from request handling
+code, ie
+
+	if err != nil {
+		exerr.Report(ctx, err)
+		...
+	}
+*/
+func Report(ctx context.Context, err error) {
+	if isNil(err) {
+		return
+	}
+	if h := reporter.Load(); h != nil {
+		h.r.Report(ctx, err)
+	}
+}
@@ -0,0 +1,135 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+SlogBackend logs the reports using [slog.Logger], every report as separate record
+with message "error report".
+*/
+type SlogBackend struct {
+	Logger *slog.Logger // when nil slog.Default() is used
+	Level  slog.Level   // level of the records, default (zero value) is slog.LevelInfo
+}
+
+func (b *SlogBackend) Send(ctx context.Context, reports []*exerr.ErrorReport) error {
+	logger := b.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	for _, r := range reports {
+		attrs := []slog.Attr{
+			slog.String("error", r.Message),
+			slog.String("fingerprint", r.Fingerprint),
+		}
+		if len(r.Fields) != 0 {
+			attrs = append(attrs, slog.Any("fields", r.Fields))
+		}
+		if len(r.Stack) != 0 {
+			attrs = append(attrs, slog.Any("stack", r.Stack))
+		}
+		if r.Build != nil {
+			attrs = append(attrs, slog.Any("build", r.Build))
+		}
+		logger.LogAttrs(ctx, b.Level, "error report", attrs...)
+	}
+	return nil
+}
+
+/*
+HTTPBackend POSTs the batch of reports as JSON array to the URL. The request is
+cancelled when the context passed to Send is done (see Options.SendTimeout).
+*/
+type HTTPBackend struct {
+	URL    string
+	Client *http.Client // when nil http.DefaultClient is used
+	Header http.Header  // additional request headers, ie for authentication
+}
+
+func (b *HTTPBackend) Send(ctx context.Context, reports []*exerr.ErrorReport) error {
+	// reports are encoded one by one so that single report which can't be encoded
+	// doesn't cause the whole batch to be lost
+	var errs []error
+	body := &bytes.Buffer{}
+	body.WriteByte('[')
+	n := 0
+	for _, r := range reports {
+		data, err := encodeReport(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if n++; n > 1 {
+			body.WriteByte(',')
+		}
+		body.Write(data)
+	}
+	body.WriteByte(']')
+	if n == 0 {
+		return errors.Join(errs...)
+	}
+	if err := b.send(ctx, body.Bytes()); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (b *HTTPBackend) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range b.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending reports: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sending reports: unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+/*
+encodeReport returns JSON encoding of the report "r". When some of the field values
+can't be encoded (ie NaN, func, chan) these are replaced with their string
+representation (as formatted by fmt.Sprint).
+*/
+func encodeReport(r *exerr.ErrorReport) ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil && len(r.Fields) != 0 {
+		c := *r
+		c.Fields = make(map[string]any, len(r.Fields))
+		for k, v := range r.Fields {
+			if _, err := json.Marshal(v); err != nil {
+				v = fmt.Sprint(v)
+			}
+			c.Fields[k] = v
+		}
+		data, err = json.Marshal(&c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding report: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,92 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func Test_SlogBackend(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	b := &SlogBackend{Logger: slog.New(slog.NewJSONHandler(buf, nil)), Level: slog.LevelWarn}
+	rep := exerr.NewErrorReport(newError(1), exerr.WithBuildInfo(), exerr.WithFingerprint())
+	if err := b.Send(context.Background(), []*exerr.ErrorReport{rep, exerr.NewErrorReport(newError(2))}); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(buf)
+	var rec struct {
+		Level       string
+		Msg         string
+		Error       string
+		Fingerprint string
+		Fields      map[string]any
+		Stack       []exerr.ReportFrame
+		Build       *exerr.BuildInfo
+	}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level != "WARN" || rec.Msg != "error report" || rec.Error != "order 1 not found" || rec.Fingerprint != rep.Fingerprint {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.Fields["order_id"] != 1.0 || len(rec.Stack) != len(rep.Stack) || rec.Build == nil {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if !dec.More() {
+		t.Error("expected record for every report")
+	}
+}
+
+func Test_HTTPBackend_unsupported_values(t *testing.T) {
+	t.Parallel()
+
+	c := newCollector(t)
+	bad := exerr.NewErrorReport(exerr.New("bad").AddField("ratio", math.NaN()).AddField("fn", func() {}).AddField("ok", 1))
+	reports := []*exerr.ErrorReport{exerr.NewErrorReport(newError(1)), bad, exerr.NewErrorReport(newError(2))}
+	if err := c.backend().Send(context.Background(), reports); err != nil {
+		t.Fatal(err)
+	}
+	r := c.reports()
+	if len(r) != 3 {
+		t.Fatalf("expected all reports to be sent, got %d", len(r))
+	}
+	if f := r[1].Fields; f["ratio"] != "NaN" || f["ok"] != 1.0 || f["fn"] == nil {
+		t.Errorf("unexpected fields %v", f)
+	}
+	if _, ok := bad.Fields["ratio"].(float64); !ok {
+		t.Error("original report must not be modified")
+	}
+}
+
+func Test_HTTPBackend_timeout(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	var errs []error
+	r := New(&HTTPBackend{URL: srv.URL}, Options{SendTimeout: 20 * time.Millisecond, OnError: func(err error) { errs = append(errs, err) }})
+	r.Report(context.Background(), newError(1))
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("expected send to time out, got %v", errs)
+	}
+}
@@ -0,0 +1,130 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+FileBackend writes the reports into file as JSON lines (one report per line). When
+the size of the file would exceed MaxSize the file is rotated: the current file is
+renamed to "name.1" (previous "name.1" to "name.2" and so on) and new file is created.
+At most MaxBackups rotated files are kept. When rotation fails the reports are
+appended to the current file (and the error is returned), rotation is retried when
+the next report is written.
+*/
+type FileBackend struct {
+	name       string
+	maxSize    int64
+	maxBackups int
+
+	mu     sync.Mutex
+	f      *os.File // nil when reopening the file after rotation failed
+	size   int64
+	closed bool
+}
+
+/*
+NewFileBackend opens (creates) file "name" for appending the reports. Zero "maxSize"
+disables rotation.
+*/
+func NewFileBackend(name string, maxSize int64, maxBackups int) (*FileBackend, error) {
+	b := &FileBackend{name: name, maxSize: maxSize, maxBackups: maxBackups}
+	if err := b.open(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *FileBackend) open() error {
+	f, err := os.OpenFile(b.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening report file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("reading report file size: %w", err)
+	}
+	b.f, b.size = f, fi.Size()
+	return nil
+}
+
+func (b *FileBackend) Send(ctx context.Context, reports []*exerr.ErrorReport) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return os.ErrClosed
+	}
+	var errs []error
+	if b.f == nil {
+		// previous rotation failed to reopen the file
+		if err := b.open(); err != nil {
+			return err
+		}
+	}
+	for _, r := range reports {
+		line, err := encodeReport(r)
+		if err != nil {
+			// skip the report, the rest of the batch can still be written
+			errs = append(errs, err)
+			continue
+		}
+		line = append(line, '\n')
+		if b.maxSize > 0 && b.size > 0 && b.size+int64(len(line)) > b.maxSize {
+			if err := b.rotate(); err != nil {
+				// keep writing into the current file when it can be (re)opened
+				errs = append(errs, err)
+				if b.f == nil {
+					if err := b.open(); err != nil {
+						return errors.Join(append(errs, err)...)
+					}
+				}
+			}
+		}
+		n, err := b.f.Write(line)
+		b.size += int64(n)
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("writing report: %w", err))...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *FileBackend) rotate() error {
+	err := b.f.Close()
+	b.f = nil
+	if err != nil {
+		return fmt.Errorf("closing report file: %w", err)
+	}
+	if b.maxBackups > 0 {
+		for i := b.maxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", b.name, i), fmt.Sprintf("%s.%d", b.name, i+1))
+		}
+		if err := os.Rename(b.name, b.name+".1"); err != nil {
+			return fmt.Errorf("rotating report file: %w", err)
+		}
+	} else if err := os.Remove(b.name); err != nil {
+		return fmt.Errorf("rotating report file: %w", err)
+	}
+	return b.open()
+}
+
+// Close closes the report file.
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if b.f == nil {
+		return nil
+	}
+	err := b.f.Close()
+	b.f = nil
+	return err
+}
@@ -0,0 +1,211 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func mustEncode(t *testing.T, r *exerr.ErrorReport) []byte {
+	t.Helper()
+	b, err := encodeReport(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func Test_FileBackend(t *testing.T) {
+	t.Parallel()
+
+	readReports := func(t *testing.T, name string) (r []*exerr.ErrorReport) {
+		t.Helper()
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range bytes.Split(bytes.TrimSpace(b), []byte("\n")) {
+			rep := &exerr.ErrorReport{}
+			if err := json.Unmarshal(line, rep); err != nil {
+				t.Fatalf("decoding %q: %v", line, err)
+			}
+			r = append(r, rep)
+		}
+		return r
+	}
+
+	reports := func(n int) (r []*exerr.ErrorReport) {
+		for i := range n {
+			r = append(r, exerr.NewErrorReport(newError(i)))
+		}
+		return r
+	}
+
+	t.Run("without rotation", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "errors.jsonl")
+		b, err := NewFileBackend(name, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(3)); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(1)); err != os.ErrClosed {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+
+		// reopening appends to the file
+		if b, err = NewFileBackend(name, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		if err := b.Send(context.Background(), reports(1)); err != nil {
+			t.Fatal(err)
+		}
+		r := readReports(t, name)
+		if len(r) != 4 || r[1].Message != "order 1 not found" {
+			t.Errorf("unexpected reports %v", r)
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "errors.jsonl")
+		line, _ := json.Marshal(reports(1)[0])
+		// room for two reports per file
+		b, err := NewFileBackend(name, int64(2*len(line)+10), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		for range 4 {
+			if err := b.Send(context.Background(), reports(2)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, n := range []string{name, name + ".1", name + ".2"} {
+			if r := readReports(t, n); len(r) != 2 {
+				t.Errorf("expected 2 reports in %s, got %d", n, len(r))
+			}
+		}
+		if _, err := os.Stat(name + ".3"); !os.IsNotExist(err) {
+			t.Errorf("expected only 2 backups to be kept, got %v", err)
+		}
+	})
+
+	t.Run("rotation without backups", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "errors.jsonl")
+		b, err := NewFileBackend(name, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		if err := b.Send(context.Background(), reports(3)); err != nil {
+			t.Fatal(err)
+		}
+		if r := readReports(t, name); len(r) != 1 || r[0].Message != "order 2 not found" {
+			t.Errorf("unexpected reports %v", r)
+		}
+	})
+
+	t.Run("rotation fails", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "errors.jsonl")
+		line := len(mustEncode(t, reports(1)[0])) + 1
+		b, err := NewFileBackend(name, int64(line), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		// renaming the file to the name of non-empty directory fails
+		if err := os.MkdirAll(filepath.Join(name+".1", "x"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(2)); err == nil || !strings.Contains(err.Error(), "rotating report file") {
+			t.Errorf("expected rotation error, got %v", err)
+		}
+		if r := readReports(t, name); len(r) != 2 {
+			t.Errorf("expected reports to be written into the current file, got %d", len(r))
+		}
+
+		// rotation succeeds once the directory is removed
+		if err := os.RemoveAll(name + ".1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r := readReports(t, name); len(r) != 1 {
+			t.Errorf("expected new file with single report, got %d", len(r))
+		}
+		if r := readReports(t, name+".1"); len(r) != 2 {
+			t.Errorf("expected rotated file with two reports, got %d", len(r))
+		}
+	})
+
+	t.Run("reopening after rotation fails", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "errors.jsonl")
+		b, err := NewFileBackend(name, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		if err := b.Send(context.Background(), reports(1)); err != nil {
+			t.Fatal(err)
+		}
+		// directory in place of the file makes both removing and reopening it fail
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(name, "x"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(1)); err == nil {
+			t.Error("expected error")
+		}
+		if err := os.RemoveAll(name); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Send(context.Background(), reports(1)); err != nil {
+			t.Errorf("expected backend to recover, got %v", err)
+		}
+		if r := readReports(t, name); len(r) != 1 {
+			t.Errorf("unexpected reports %v", r)
+		}
+	})
+
+	t.Run("unsupported field value", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "errors.jsonl")
+		b, err := NewFileBackend(name, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer b.Close()
+		batch := reports(2)
+		batch = slices.Insert(batch, 1, exerr.NewErrorReport(exerr.New("bad").AddField("ratio", math.Inf(1))))
+		if err := b.Send(context.Background(), batch); err != nil {
+			t.Fatal(err)
+		}
+		r := readReports(t, name)
+		if len(r) != 3 || r[1].Fields["ratio"] != "+Inf" || r[2].Message != "order 1 not found" {
+			t.Errorf("unexpected reports %v", r)
+		}
+	})
+
+	t.Run("invalid file name", func(t *testing.T) {
+		if _, err := NewFileBackend(filepath.Join(t.TempDir(), "x", "errors.jsonl"), 0, 0); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,292 @@
+/*
+Package reporter implements [exerr.Reporter] which sends error reports asynchronously,
+in batches, to a [Backend] (error tracking system, log, file,...).
+
+	r := reporter.New(&reporter.HTTPBackend{URL: "https://errors.example.com/api/reports"}, reporter.Options{})
+	defer r.Close(context.Background())
+	exerr.SetReporter(r)
+
+Reports are queued and sent by background goroutine, when the queue is full new
+reports are dropped so that reporting never blocks the caller. Reports can be sampled
+and rate limited per [exerr.Fingerprint] of the error.
+*/
+package reporter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+/*
+Backend sends batch of reports to the destination. Send is called from single
+goroutine, ie calls are not concurrent. The context passed to Send has timeout (see
+Options.SendTimeout) and it is cancelled when [Reporter.Close] gives up waiting,
+backend must return when the context is done.
+*/
+type Backend interface {
+	Send(ctx context.Context, reports []*exerr.ErrorReport) error
+}
+
+// Options of the [Reporter], zero value means default for all the fields.
+type Options struct {
+	QueueSize     int           // max number of reports waiting to be sent, default 1000
+	BatchSize     int           // max number of reports sent in single batch, default 100
+	FlushInterval time.Duration // how often queued reports are sent, default 5s
+	SendTimeout   time.Duration // timeout of sending single batch, default 30s
+
+	// SampleRate is the probability (0 < rate <= 1) of the error being reported,
+	// default is 1 ie all errors are reported.
+	SampleRate float64
+
+	// RateLimit is the max number of reports of the errors with the same fingerprint
+	// sent within RateWindow (default one minute). Zero means no limit.
+	RateLimit  int
+	RateWindow time.Duration
+
+	// ReportOptions are used to create the reports, default is to include the build
+	// info. Fingerprint is included when RateLimit is set (computing it requires
+	// symbolizing the stack, which exerr.WithRawStack option otherwise avoids).
+	ReportOptions []exerr.ReportOption
+
+	// OnError is called when the backend fails to send reports, default is to log
+	// the error using slog.Default().
+	OnError func(error)
+}
+
+func (o *Options) setDefaults() {
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1000
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+	if o.SendTimeout <= 0 {
+		o.SendTimeout = 30 * time.Second
+	}
+	if o.SampleRate <= 0 || o.SampleRate > 1 {
+		o.SampleRate = 1
+	}
+	if o.RateWindow <= 0 {
+		o.RateWindow = time.Minute
+	}
+	if o.ReportOptions == nil {
+		o.ReportOptions = []exerr.ReportOption{exerr.WithBuildInfo()}
+	}
+	if o.RateLimit > 0 {
+		// clip so that the caller's slice is not modified
+		o.ReportOptions = append(slices.Clip(o.ReportOptions), exerr.WithFingerprint())
+	}
+	if o.OnError == nil {
+		o.OnError = func(err error) {
+			slog.Default().Error("sending error reports", "err", err)
+		}
+	}
+}
+
+/*
+Reporter is the [exerr.Reporter] which sends reports asynchronously to the [Backend].
+Must be created with [New] and closed with [Reporter.Close].
+*/
+type Reporter struct {
+	backend Backend
+	opts    Options
+
+	queue   chan *exerr.ErrorReport
+	flush   chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	closed  atomic.Bool
+	dropped atomic.Uint64
+
+	// ctx is the parent of the contexts passed to the backend, cancel is called
+	// when Close gives up waiting for the queued reports to be sent
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	limits map[string]*window // fingerprint -> reports sent in the current window
+	now    func() time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+/*
+New creates Reporter which sends reports to the backend "b" and starts the background
+goroutine sending the reports.
+*/
+func New(b Backend, opts Options) *Reporter {
+	opts.setDefaults()
+	r := &Reporter{
+		backend: b,
+		opts:    opts,
+		queue:   make(chan *exerr.ErrorReport, opts.QueueSize),
+		flush:   make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		limits:  make(map[string]*window),
+		now:     time.Now,
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	go r.run()
+	return r
+}
+
+/*
+Report queues report of the error "err" to be sent to the backend. The report is
+dropped when it's not sampled, the rate limit of it's fingerprint has been exceeded
+or the queue is full.
+*/
+func (r *Reporter) Report(ctx context.Context, err error) {
+	if err == nil || r.closed.Load() {
+		return
+	}
+	if r.opts.SampleRate < 1 && rand.Float64() >= r.opts.SampleRate {
+		return
+	}
+
+	rep := exerr.NewErrorReport(err, r.opts.ReportOptions...)
+	if !r.allow(rep.Fingerprint) {
+		return
+	}
+	select {
+	case r.queue <- rep:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+/*
+Dropped returns number of the reports dropped because the queue was full.
+*/
+func (r *Reporter) Dropped() uint64 { return r.dropped.Load() }
+
+// allow returns true when report of the error with fingerprint "fp" is within the rate limit.
+func (r *Reporter) allow(fp string) bool {
+	if r.opts.RateLimit <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	w, ok := r.limits[fp]
+	if !ok || now.Sub(w.start) >= r.opts.RateWindow {
+		if len(r.limits) >= 10000 {
+			// don't let the map grow without bounds, forget expired windows
+			for k, v := range r.limits {
+				if now.Sub(v.start) >= r.opts.RateWindow {
+					delete(r.limits, k)
+				}
+			}
+		}
+		w = &window{start: now}
+		r.limits[fp] = w
+	}
+	w.count++
+	return w.count <= r.opts.RateLimit
+}
+
+/*
+Flush sends all the queued reports to the backend and waits until they are sent
+(or the context is cancelled).
+*/
+func (r *Reporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case r.flush <- done:
+	case <-r.done:
+		return errors.New("reporter is closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Close sends the queued reports to the backend and stops the reporter. Reports of the
+errors reported after Close are dropped. When "ctx" is done before the queued reports
+have been sent the ongoing Send is cancelled and the rest of the reports are dropped.
+*/
+func (r *Reporter) Close(ctx context.Context) error {
+	if r.closed.CompareAndSwap(false, true) {
+		close(r.stop)
+	}
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *Reporter) run() {
+	defer close(r.done)
+	defer r.cancel()
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*exerr.ErrorReport, 0, r.opts.BatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.ctx, r.opts.SendTimeout)
+		if err := r.backend.Send(ctx, batch); err != nil {
+			r.opts.OnError(err)
+		}
+		cancel()
+		batch = make([]*exerr.ErrorReport, 0, r.opts.BatchSize)
+	}
+	add := func(rep *exerr.ErrorReport) {
+		if batch = append(batch, rep); len(batch) >= r.opts.BatchSize {
+			send()
+		}
+	}
+	// drain sends all the reports currently in the queue
+	drain := func() {
+		for {
+			select {
+			case rep := <-r.queue:
+				add(rep)
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case rep := <-r.queue:
+			add(rep)
+		case <-ticker.C:
+			send()
+		case done := <-r.flush:
+			drain()
+			close(done)
+		case <-r.stop:
+			drain()
+			return
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// collector is httptest server receiving reports sent by HTTPBackend.
+type collector struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches [][]*exerr.ErrorReport
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var batch []*exerr.ErrorReport
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.batches = append(c.batches, batch)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) reports() (r []*exerr.ErrorReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, b := range c.batches {
+		r = append(r, b...)
+	}
+	return r
+}
+
+func (c *collector) backend() *HTTPBackend {
+	return &HTTPBackend{URL: c.URL, Header: http.Header{"Authorization": {"secret"}}}
+}
+
+func newError(id int) error {
+	return exerr.Errorf("order %d not found", id).AddField("order_id", id)
+}
+
+func Test_Reporter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports are sent on flush", func(t *testing.T) {
+		c := newCollector(t)
+		r := New(c.backend(), Options{FlushInterval: time.Hour})
+		defer r.Close(context.Background())
+
+		r.Report(context.Background(), fmt.Errorf("wrapped: %w", newError(1)))
+		r.Report(context.Background(), nil)
+		if err := r.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		reps := c.reports()
+		if len(reps) != 1 {
+			t.Fatalf("expected single report, got %d", len(reps))
+		}
+		rep := reps[0]
+		if rep.Message != "wrapped: order 1 not found" || rep.Fields["order_id"] != 1.0 {
+			t.Errorf("unexpected report %+v", rep)
+		}
+		if len(rep.Stack) == 0 || rep.Build == nil {
+			t.Errorf("expected stack and build info in report %+v", rep)
+		}
+		if rep.Fingerprint != "" {
+			t.Errorf("fingerprint is not needed without rate limit, got %q", rep.Fingerprint)
+		}
+	})
+
+	t.Run("batching", func(t *testing.T) {
+		c := newCollector(t)
+		r := New(c.backend(), Options{BatchSize: 3, FlushInterval: time.Hour})
+		for i := range 7 {
+			r.Report(context.Background(), newError(i))
+		}
+		if err := r.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(c.reports()) != 7 {
+			t.Fatalf("expected 7 reports, got %d", len(c.reports()))
+		}
+		for _, b := range c.batches {
+			if len(b) > 3 {
+				t.Errorf("batch size exceeds the limit: %d", len(b))
+			}
+		}
+
+		// reports after close are dropped
+		r.Report(context.Background(), newError(8))
+		if err := r.Flush(context.Background()); err == nil {
+			t.Error("expected error flushing closed reporter")
+		}
+		if len(c.reports()) != 7 {
+			t.Errorf("expected 7 reports, got %d", len(c.reports()))
+		}
+	})
+
+	t.Run("flush interval", func(t *testing.T) {
+		c := newCollector(t)
+		r := New(c.backend(), Options{FlushInterval: 10 * time.Millisecond})
+		defer r.Close(context.Background())
+
+		r.Report(context.Background(), newError(1))
+		deadline := time.Now().Add(5 * time.Second)
+		for len(c.reports()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if len(c.reports()) != 1 {
+			t.Error("expected report to be sent without flush")
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		c := newCollector(t)
+		r := New(c.backend(), Options{RateLimit: 2, FlushInterval: time.Hour})
+		defer r.Close(context.Background())
+		now := time.Now()
+		r.now = func() time.Time { return now }
+
+		for i := range 5 {
+			r.Report(context.Background(), newError(i)) // same fingerprint
+		}
+		r.Report(context.Background(), errors.New("other"))
+		now = now.Add(time.Minute)
+		r.Report(context.Background(), newError(10))
+
+		if err := r.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		reps := c.reports()
+		if n := len(reps); n != 4 {
+			t.Fatalf("expected 4 reports, got %d", n)
+		}
+		if reps[0].Fingerprint == "" || reps[0].Fingerprint != reps[1].Fingerprint {
+			t.Errorf("expected reports to have the same fingerprint, got %q and %q", reps[0].Fingerprint, reps[1].Fingerprint)
+		}
+	})
+
+	t.Run("report options of the caller are not modified", func(t *testing.T) {
+		opts := make([]exerr.ReportOption, 1, 2)
+		opts[0] = exerr.WithRawStack()
+		spare := opts[:2]
+		r := New(backendFunc(func(ctx context.Context, reports []*exerr.ErrorReport) error { return nil }), Options{ReportOptions: opts, RateLimit: 1})
+		defer r.Close(context.Background())
+		if spare[1] != nil {
+			t.Error("expected spare capacity of the caller's slice not to be used")
+		}
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		c := newCollector(t)
+		r := New(c.backend(), Options{SampleRate: 0.5, QueueSize: 2000, FlushInterval: time.Hour})
+		defer r.Close(context.Background())
+
+		for range 1000 {
+			r.Report(context.Background(), errors.New("sampled"))
+		}
+		if err := r.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(c.reports()); n < 350 || n > 650 {
+			t.Errorf("expected about half of the reports to be sent, got %d", n)
+		}
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		block := make(chan struct{})
+		b := backendFunc(func(ctx context.Context, reports []*exerr.ErrorReport) error {
+			<-block
+			return nil
+		})
+		r := New(b, Options{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour})
+		defer r.Close(context.Background())
+
+		for range 10 {
+			r.Report(context.Background(), errors.New("x"))
+		}
+		close(block)
+		// first report might be in the backend, two in the queue
+		if n := r.Dropped(); n < 7 {
+			t.Errorf("expected at least 7 reports to be dropped, got %d", n)
+		}
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		c := newCollector(t)
+		var errs []error
+		b := c.backend()
+		b.Header = nil // collector rejects requests without authorization
+		r := New(b, Options{OnError: func(err error) { errs = append(errs, err) }})
+		r.Report(context.Background(), newError(1))
+		if err := r.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) != 1 || errs[0].Error() != "sending reports: unexpected response status 400 Bad Request" {
+			t.Errorf("unexpected errors %v", errs)
+		}
+	})
+
+	t.Run("close cancels ongoing send", func(t *testing.T) {
+		cancelled := make(chan error, 1)
+		r := New(backendFunc(func(ctx context.Context, reports []*exerr.ErrorReport) error {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return ctx.Err()
+		}), Options{OnError: func(error) {}})
+
+		r.Report(context.Background(), errors.New("x"))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := r.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if err := <-cancelled; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected send to be cancelled, got %v", err)
+		}
+		if err := r.Close(context.Background()); err != nil {
+			t.Errorf("expected reporter to stop, got %v", err)
+		}
+	})
+
+	t.Run("flush with cancelled context", func(t *testing.T) {
+		block := make(chan struct{})
+		r := New(backendFunc(func(ctx context.Context, reports []*exerr.ErrorReport) error {
+			<-block
+			return nil
+		}), Options{})
+		defer r.Close(context.Background())
+		defer close(block)
+
+		r.Report(context.Background(), errors.New("x"))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := r.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
+
+func Test_exerr_Report(t *testing.T) {
+	c := newCollector(t)
+	r := New(c.backend(), Options{})
+	exerr.SetReporter(r)
+	t.Cleanup(func() { exerr.SetReporter(nil) })
+
+	exerr.Report(context.Background(), newError(1))
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.reports()) != 1 {
+		t.Errorf("expected single report, got %d", len(c.reports()))
+	}
+}
+
+type backendFunc func(ctx context.Context, reports []*exerr.ErrorReport) error
+
+func (f backendFunc) Send(ctx context.Context, reports []*exerr.ErrorReport) error {
+	return f(ctx, reports)
+}
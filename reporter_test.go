@@ -0,0 +1,35 @@
+package exerr
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+type reporterFunc func(ctx context.Context, err error)
+
+func (f reporterFunc) Report(ctx context.Context, err error) { f(ctx, err) }
+
+func Test_Report(t *testing.T) {
+	old := reporter.Load()
+	t.Cleanup(func() { reporter.Store(old) })
+
+	// no reporter set, must not panic
+	SetReporter(nil)
+	Report(context.Background(), io.EOF)
+
+	var got []error
+	SetReporter(reporterFunc(func(ctx context.Context, err error) { got = append(got, err) }))
+	Report(context.Background(), nil)
+	Report(context.Background(), (*exErr)(nil))
+	Report(context.Background(), io.EOF)
+	if len(got) != 1 || got[0] != io.EOF {
+		t.Errorf("unexpected reported errors %v", got)
+	}
+
+	SetReporter(nil)
+	Report(context.Background(), io.EOF)
+	if len(got) != 1 {
+		t.Errorf("reporter was disabled but error was reported: %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+)
+
+type field struct {
+	name  string
+	value any
+}
+
+/*
+kvFields converts list of alternating field names and values into fields. Name
+which is not a string is converted to string using [fmt.Sprint], when the list
+has odd number of items the value of the last field is nil.
+*/
+func kvFields(kv []any) []field {
+	r := make([]field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		f := field{}
+		if s, ok := kv[i].(string); ok {
+			f.name = s
+		} else {
+			f.name = fmt.Sprint(kv[i])
+		}
+		if i+1 < len(kv) {
+			f.value = kv[i+1]
+		}
+		r = append(r, f)
+	}
+	return r
+}
+
+/*
+Builder creates errors with predefined set of fields, see [Scope].
+*/
+type Builder struct {
+	fields []field
+}
+
+/*
+Scope returns error builder which attaches fields "kv" (list of alternating field
+names and values) to every error it creates. Useful when the same fields should be
+attached to every error returned by the function or component:
+
+	b := exerr.Scope("op", "CreateOrder", "user", uid)
+	...
+	if err != nil {
+		return b.Errorf("loading cart: %w", err)
+	}
+
+The stack trace of the errors is captured where the error is created, not where
+the builder is created. Builder is immutable and thus safe for concurrent use.
+*/
+func Scope(kv ...any) *Builder {
+	return &Builder{fields: kvFields(kv)}
+}
+
+/*
+With returns new builder which has all the fields of "b" plus field "name" with "value".
+When "b" already has the field "name" the new value overrides it.
+*/
+func (b *Builder) With(name string, value any) *Builder {
+	fields := make([]field, len(b.fields), len(b.fields)+1)
+	copy(fields, b.fields)
+	return &Builder{fields: append(fields, field{name: name, value: value})}
+}
+
+// New is like [New] but the error has fields of the builder attached.
+func (b *Builder) New(text string) ErrorWithFields {
+	return b.attach(newExErr(errors.New(text)))
+}
+
+// Errorf is like [Errorf] but the error has fields of the builder attached.
+func (b *Builder) Errorf(format string, a ...any) ErrorWithFields {
+	e := newExErr(fmt.Errorf(format, a...))
+	e.setTemplate(format, a)
+	return b.attach(e)
+}
+
+/*
+Wrap returns new error which wraps "err" and has fields of the builder attached.
+Returns nil when "err" is nil.
+*/
+func (b *Builder) Wrap(err error) ErrorWithFields {
+	if isNil(err) {
+		return nil
+	}
+	return b.attach(newExErr(err))
+}
+
+func (b *Builder) attach(e *exErr) *exErr {
+	for _, f := range b.fields {
+		e.AddField(f.name, f.value)
+	}
+	return e
+}
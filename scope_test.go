@@ -0,0 +1,161 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_kvFields(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		kv  []any
+		exp []field
+	}{
+		{kv: nil, exp: []field{}},
+		{kv: []any{"a", 1}, exp: []field{{name: "a", value: 1}}},
+		{kv: []any{"a", 1, "b", "foo"}, exp: []field{{name: "a", value: 1}, {name: "b", value: "foo"}}},
+		{kv: []any{"a"}, exp: []field{{name: "a", value: nil}}},
+		{kv: []any{"a", 1, 2}, exp: []field{{name: "a", value: 1}, {name: "2", value: nil}}},
+		{kv: []any{42, true}, exp: []field{{name: "42", value: true}}},
+	}
+
+	for _, tc := range testCases {
+		if r := kvFields(tc.kv); !reflect.DeepEqual(r, tc.exp) {
+			t.Errorf("kvFields(%v): expected %v, got %v", tc.kv, tc.exp, r)
+		}
+	}
+}
+
+func Test_Scope(t *testing.T) {
+	t.Parallel()
+
+	b := Scope("op", "CreateOrder", "user", 42)
+
+	expectScopeFields := func(t *testing.T, err error) {
+		t.Helper()
+		flds := Fields(err)
+		if n := len(flds); n != 2 {
+			t.Errorf("expected 2 fields, got %v", flds)
+		}
+		containsField(t, flds, "op", "CreateOrder")
+		containsField(t, flds, "user", 42)
+	}
+
+	t.Run("New", func(t *testing.T) {
+		err := b.New("some error")
+		if msg := err.Error(); msg != "some error" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		expectScopeFields(t, err)
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Scope.func2" {
+			t.Errorf("expected stack to be captured where the error was created, got %v", f)
+		}
+	})
+
+	t.Run("Errorf", func(t *testing.T) {
+		err := b.Errorf("reading %s: %w", "foo", io.EOF).AddField("extra", 1)
+		if msg := err.Error(); msg != "reading foo: EOF" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Error("expected wrapped error to be detected")
+		}
+		expectFieldValue(t, err, "op", "CreateOrder")
+		expectFieldValue(t, err, "extra", 1)
+		if f, _ := Template(err); f != "reading %s: %w" {
+			t.Errorf("unexpected template %q", f)
+		}
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Scope.func3" {
+			t.Errorf("expected stack to be captured where the error was created, got %v", f)
+		}
+	})
+
+	t.Run("Wrap", func(t *testing.T) {
+		if err := b.Wrap(nil); err != nil {
+			t.Errorf("expected nil for nil input, got %v", err)
+		}
+
+		origErr := fmt.Errorf("some error")
+		err := b.Wrap(origErr)
+		if !errors.Is(err, origErr) {
+			t.Error("expected wrapped error to be detected")
+		}
+		if msg := err.Error(); msg != "some error" {
+			t.Errorf("unexpected message %q", msg)
+		}
+		expectScopeFields(t, err)
+		if f, _ := Origin(err); f.Function != "github.com/ainvaltin/exerr.Test_Scope.func4" {
+			t.Errorf("expected stack to be captured where the error was created, got %v", f)
+		}
+	})
+
+	t.Run("Wrap exerr error", func(t *testing.T) {
+		inner := Errorf("inner: %w", io.EOF).AddField("id", 1)
+		err := b.Wrap(inner)
+		if !errors.Is(err, inner) || !errors.Is(err, io.EOF) {
+			t.Error("expected wrapped errors to be detected")
+		}
+		flds := Fields(err)
+		if n := len(flds); n != 3 {
+			t.Errorf("expected 3 fields, got %v", flds)
+		}
+		containsField(t, flds, "op", "CreateOrder")
+		containsField(t, flds, "id", 1)
+
+		var origin error
+		for _, f := range FieldsWithOrigin(err) {
+			if f.Name == "id" {
+				origin = f.Err
+			}
+		}
+		if origin != inner {
+			t.Errorf("expected field to be attached to the wrapped error, got %v", origin)
+		}
+		if f, _ := Template(err); f != "inner: %w" {
+			t.Errorf("expected template of the wrapped error, got %q", f)
+		}
+	})
+
+	t.Run("errors do not share fields", func(t *testing.T) {
+		errA := b.New("A").AddField("a", 1)
+		errB := b.New("B")
+		if _, ok := FieldValue(errB, "a"); ok {
+			t.Error("field added to one error is visible in another")
+		}
+		expectFieldValue(t, errA, "a", 1)
+	})
+
+	t.Run("With", func(t *testing.T) {
+		nested := b.With("resource", "cart").With("user", 7)
+		err := nested.New("some error")
+		flds := Fields(err)
+		if n := len(flds); n != 3 {
+			t.Errorf("expected 3 fields, got %v", flds)
+		}
+		containsField(t, flds, "op", "CreateOrder")
+		containsField(t, flds, "resource", "cart")
+		containsField(t, flds, "user", 7)
+
+		// parent scope must not be affected
+		expectScopeFields(t, b.New("some error"))
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.With("i", i).Errorf("error %d", i)
+				expectFieldValue(t, err, "i", i)
+				expectFieldValue(t, err, "user", 42)
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,124 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+Client sends errors to Sentry as events, see [NewClient].
+*/
+type Client struct {
+	EventOptions
+
+	Release     string
+	Environment string
+	ServerName  string
+
+	HTTPClient *http.Client // when nil http.DefaultClient is used
+
+	dsn       string
+	publicKey string
+	endpoint  string // URL of the envelope endpoint
+}
+
+/*
+NewClient creates Client for the project identified by "dsn", ie
+
+	https://<public key>@o0.ingest.sentry.io/<project id>
+*/
+func NewClient(dsn string) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid DSN: unsupported scheme %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid DSN: public key is missing")
+	}
+	// project ID is the last element of the path, DSN may contain path prefix, ie "/prefix/42"
+	i := strings.LastIndexByte(u.Path, '/')
+	path, project := u.Path[:max(i, 0)], u.Path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("invalid DSN: project ID is missing")
+	}
+
+	return &Client{
+		dsn:       dsn,
+		publicKey: u.User.Username(),
+		endpoint:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path, project),
+	}, nil
+}
+
+/*
+Capture converts error "err" into event (see [NewEvent]) and sends it, returns the
+ID of the event.
+*/
+func (c *Client) Capture(ctx context.Context, err error) (string, error) {
+	ev := NewEvent(err, c.EventOptions)
+	ev.Release, ev.Environment, ev.ServerName = c.Release, c.Environment, c.ServerName
+	return ev.EventID, c.Send(ctx, ev)
+}
+
+/*
+Send posts the event "ev" to the Sentry envelope endpoint.
+*/
+func (c *Client) Send(ctx context.Context, ev *Event) error {
+	body, err := encodeEnvelope(ev, c.dsn, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=exerr/1, sentry_key=%s", c.publicKey))
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sending event: unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+/*
+encodeEnvelope returns envelope containing single event item: envelope header,
+item header and the event, each on it's own line.
+*/
+func encodeEnvelope(ev *Event, dsn string, sentAt time.Time) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("encoding event: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(map[string]any{"event_id": ev.EventID, "sent_at": sentAt.UTC(), "dsn": dsn}); err != nil {
+		return nil, fmt.Errorf("encoding envelope header: %w", err)
+	}
+	if err := enc.Encode(map[string]any{"type": "event", "length": len(payload)}); err != nil {
+		return nil, fmt.Errorf("encoding item header: %w", err)
+	}
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+package sentry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func Test_NewClient(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		dsn      string
+		endpoint string
+		err      string
+	}{
+		{dsn: "https://key@o1.ingest.sentry.io/42", endpoint: "https://o1.ingest.sentry.io/api/42/envelope/"},
+		{dsn: "http://key@localhost:9000/prefix/7", endpoint: "http://localhost:9000/prefix/api/7/envelope/"},
+		{dsn: "ftp://key@host/1", err: `invalid DSN: unsupported scheme "ftp"`},
+		{dsn: "https://host/1", err: "invalid DSN: public key is missing"},
+		{dsn: "https://key@host/", err: "invalid DSN: project ID is missing"},
+		{dsn: "https://key@host", err: "invalid DSN: project ID is missing"},
+		{dsn: "://", err: "parsing DSN: parse \"://\": missing protocol scheme"},
+	}
+	for _, tc := range testCases {
+		c, err := NewClient(tc.dsn)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: expected error %q, got %v", tc.dsn, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.dsn, err)
+			continue
+		}
+		if c.endpoint != tc.endpoint || c.publicKey != "key" {
+			t.Errorf("%s: unexpected endpoint %q or key %q", tc.dsn, c.endpoint, c.publicKey)
+		}
+	}
+}
+
+func Test_Client_Capture(t *testing.T) {
+	t.Parallel()
+
+	type envelope struct {
+		header struct {
+			EventID string `json:"event_id"`
+			DSN     string `json:"dsn"`
+			SentAt  string `json:"sent_at"`
+		}
+		item struct {
+			Type   string `json:"type"`
+			Length int    `json:"length"`
+		}
+		payload []byte
+	}
+	received := make(chan envelope, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/envelope/" || r.Header.Get("Content-Type") != "application/x-sentry-envelope" ||
+			!strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		var env envelope
+		sc := bufio.NewScanner(r.Body)
+		for i := 0; sc.Scan(); i++ {
+			var err error
+			switch i {
+			case 0:
+				err = json.Unmarshal(sc.Bytes(), &env.header)
+			case 1:
+				err = json.Unmarshal(sc.Bytes(), &env.item)
+			case 2:
+				env.payload = append([]byte(nil), sc.Bytes()...)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		received <- env
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "://", "://public@", 1) + "/42"
+	c, err := NewClient(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Release, c.Environment = "v1.0.0", "test"
+	c.Tags = []string{"user_id"}
+
+	id, err := c.Capture(context.Background(), exerr.New("failed").AddField("user_id", 42))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env := <-received
+	if env.header.EventID != id || env.header.DSN != dsn || env.header.SentAt == "" {
+		t.Errorf("unexpected envelope header %+v", env.header)
+	}
+	if env.item.Type != "event" || env.item.Length != len(env.payload) {
+		t.Errorf("unexpected item header %+v (payload length %d)", env.item, len(env.payload))
+	}
+	var ev Event
+	if err := json.Unmarshal(env.payload, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.EventID != id || ev.Release != "v1.0.0" || ev.Environment != "test" || ev.Tags["user_id"] != "42" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if len(ev.Exception.Values) != 1 || ev.Exception.Values[0].Value != "failed" || ev.Exception.Values[0].Stacktrace == nil {
+		t.Errorf("unexpected exceptions %+v", ev.Exception)
+	}
+
+	// server rejects the request
+	c.publicKey = "other"
+	if _, err := c.Capture(context.Background(), exerr.New("failed")); err == nil || err.Error() != "sending event: unexpected response status 400 Bad Request" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
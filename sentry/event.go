@@ -0,0 +1,204 @@
+/*
+Package sentry converts exerr errors into Sentry events and sends them to Sentry (or
+compatible error tracker) using the envelope protocol, without depending on the
+Sentry SDK.
+
+	client, err := sentry.NewClient(dsn)
+	...
+	client.Release = version
+	id, err := client.Capture(ctx, err)
+*/
+package sentry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// Event is (subset of the) Sentry event.
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   *ExceptionList    `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+type ExceptionList struct {
+	Values []Exception `json:"values"`
+}
+
+/*
+Exception describes single error of the chain. The exceptions of the event are ordered
+from the innermost (the cause) to the outermost error.
+*/
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+	Mechanism  *Mechanism  `json:"mechanism,omitempty"`
+}
+
+// Mechanism describes the relationship of the exception to the other exceptions of the event.
+type Mechanism struct {
+	Type             string `json:"type"`
+	ExceptionID      int    `json:"exception_id"`
+	ParentID         *int   `json:"parent_id,omitempty"`
+	IsExceptionGroup bool   `json:"is_exception_group,omitempty"`
+}
+
+// Stacktrace frames are ordered from the oldest call to the most recent one.
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+/*
+EventOptions control how the error is converted into event.
+*/
+type EventOptions struct {
+	// InApp is list of the package path prefixes of the "application code", frames
+	// of these packages are marked as "in_app". Default is the main module path.
+	InApp []string
+
+	// Tags is the list of field names which are sent as tags (value converted to
+	// string), other fields are sent as "extra" data.
+	Tags []string
+}
+
+/*
+NewEvent converts error "err" into Sentry event. Every error in the chain of "err"
+becomes an exception with the stack trace of that error (if it has one), fields of
+the errors become tags or extra data.
+*/
+func NewEvent(err error, opts EventOptions) *Event {
+	ev := &Event{
+		EventID:   newEventID(),
+		Timestamp: time.Now().UTC(),
+		Platform:  "go",
+		Level:     "error",
+	}
+	if err == nil {
+		return ev
+	}
+
+	inApp := opts.InApp
+	if inApp == nil {
+		if bi := exerr.ReadBuildInfo(); bi != nil && bi.Path != "" {
+			inApp = []string{bi.Path}
+		}
+	}
+
+	var excs []Exception
+	var parents []int // exception ID of the error at each depth
+	exerr.Walk(err, func(e error, depth int, path []int) bool {
+		exc := Exception{
+			Type:       typeName(e),
+			Value:      e.Error(),
+			Stacktrace: stacktrace(exerr.OwnFrames(e), inApp),
+			Mechanism:  &Mechanism{Type: "generic", ExceptionID: len(excs)},
+		}
+		if depth > 0 {
+			parent := parents[depth-1]
+			exc.Mechanism.Type = "chained"
+			exc.Mechanism.ParentID = &parent
+			if path[depth-1] > 0 {
+				// second child of the multi error
+				excs[parent].Mechanism.IsExceptionGroup = true
+			}
+		}
+		parents = append(parents[:depth], len(excs))
+		excs = append(excs, exc)
+		return true
+	})
+	if len(excs) == 1 {
+		excs[0].Mechanism = nil
+	}
+	slices.Reverse(excs)
+	ev.Exception = &ExceptionList{Values: excs}
+
+	for k, v := range exerr.Fields(err) {
+		if slices.Contains(opts.Tags, k) {
+			if ev.Tags == nil {
+				ev.Tags = make(map[string]string)
+			}
+			ev.Tags[k] = fmt.Sprint(v)
+		} else {
+			if ev.Extra == nil {
+				ev.Extra = make(map[string]any)
+			}
+			if _, err := json.Marshal(v); err != nil {
+				// value which can't be encoded would make the whole event unsendable
+				v = fmt.Sprint(v)
+			}
+			ev.Extra[k] = v
+		}
+	}
+	ev.Fingerprint = []string{exerr.Fingerprint(err)}
+	return ev
+}
+
+/*
+typeName returns name of the type of the error "err", errors created by exerr are
+reported as "exerr.Error".
+*/
+func typeName(err error) string {
+	s := fmt.Sprintf("%T", err)
+	if s == "*exerr.exErr" {
+		return "exerr.Error"
+	}
+	return s
+}
+
+func stacktrace(frames []exerr.Frame, inApp []string) *Stacktrace {
+	if len(frames) == 0 {
+		return nil
+	}
+	st := &Stacktrace{Frames: make([]Frame, 0, len(frames))}
+	for _, f := range slices.Backward(frames) {
+		pkg := f.Package()
+		st.Frames = append(st.Frames, Frame{
+			Function: strings.TrimPrefix(f.Function, pkg+"."),
+			Module:   pkg,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    isInApp(pkg, inApp),
+		})
+	}
+	return st
+}
+
+func isInApp(pkg string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if pkg == p || strings.HasPrefix(pkg, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
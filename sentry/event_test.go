@@ -0,0 +1,173 @@
+package sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func Test_NewEvent(t *testing.T) {
+	t.Parallel()
+
+	opts := EventOptions{InApp: []string{"github.com/ainvaltin/exerr/sentry"}, Tags: []string{"user_id"}}
+
+	t.Run("nil error", func(t *testing.T) {
+		ev := NewEvent(nil, opts)
+		if ev.Exception != nil || len(ev.EventID) != 32 || ev.Platform != "go" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	})
+
+	t.Run("single error", func(t *testing.T) {
+		err := exerr.New("failed").AddField("user_id", 42).AddField("query", "q")
+		ev := NewEvent(err, opts)
+		if ev.Level != "error" || ev.Timestamp.IsZero() {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		if len(ev.Exception.Values) != 1 {
+			t.Fatalf("expected single exception, got %d", len(ev.Exception.Values))
+		}
+		exc := ev.Exception.Values[0]
+		if exc.Type != "exerr.Error" || exc.Value != "failed" || exc.Mechanism != nil {
+			t.Errorf("unexpected exception %+v", exc)
+		}
+		frames := exc.Stacktrace.Frames
+		last := frames[len(frames)-1]
+		if last.Function != "Test_NewEvent.func2" || last.Module != "github.com/ainvaltin/exerr/sentry" || !last.InApp {
+			t.Errorf("expected the most recent frame to be the last one, got %+v", last)
+		}
+		if !strings.HasSuffix(last.AbsPath, "event_test.go") || last.Lineno == 0 {
+			t.Errorf("unexpected location of the frame %+v", last)
+		}
+		if frames[0].InApp {
+			t.Errorf("runtime frame must not be in app: %+v", frames[0])
+		}
+		if ev.Tags["user_id"] != "42" || ev.Extra["query"] != "q" || len(ev.Tags) != 1 || len(ev.Extra) != 1 {
+			t.Errorf("unexpected tags %v and extra %v", ev.Tags, ev.Extra)
+		}
+		if len(ev.Fingerprint) != 1 || ev.Fingerprint[0] != exerr.Fingerprint(err) {
+			t.Errorf("unexpected fingerprint %v", ev.Fingerprint)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		inner := exerr.New("inner")
+		err := fmt.Errorf("outer: %w", exerr.Wrap(inner, "middle"))
+		excs := NewEvent(err, opts).Exception.Values
+		if len(excs) != 3 {
+			t.Fatalf("expected 3 exceptions, got %d", len(excs))
+		}
+		// innermost first
+		exp := []struct {
+			typ, value string
+			id         int
+			parent     int
+			stack      bool
+		}{
+			{typ: "exerr.Error", value: "inner", id: 2, parent: 1, stack: true},
+			{typ: "exerr.Error", value: "middle: inner", id: 1, parent: 0, stack: true},
+			{typ: "*fmt.wrapError", value: "outer: middle: inner", id: 0, parent: -1},
+		}
+		for i, e := range exp {
+			exc := excs[i]
+			if exc.Type != e.typ || exc.Value != e.value || exc.Mechanism.ExceptionID != e.id || (exc.Stacktrace != nil) != e.stack {
+				t.Errorf("[%d] unexpected exception %+v", i, exc)
+			}
+			if e.parent < 0 {
+				if exc.Mechanism.ParentID != nil || exc.Mechanism.Type != "generic" {
+					t.Errorf("[%d] unexpected mechanism of the root %+v", i, exc.Mechanism)
+				}
+			} else if exc.Mechanism.ParentID == nil || *exc.Mechanism.ParentID != e.parent || exc.Mechanism.Type != "chained" {
+				t.Errorf("[%d] unexpected mechanism %+v", i, exc.Mechanism)
+			}
+		}
+		if excs[0].Stacktrace.Frames[len(excs[0].Stacktrace.Frames)-1].Lineno == excs[1].Stacktrace.Frames[len(excs[1].Stacktrace.Frames)-1].Lineno {
+			t.Error("expected every exception to have it's own stack trace")
+		}
+	})
+
+	t.Run("exception group", func(t *testing.T) {
+		err := errors.Join(io.EOF, exerr.New("second"))
+		excs := NewEvent(err, opts).Exception.Values
+		if len(excs) != 3 {
+			t.Fatalf("expected 3 exceptions, got %d", len(excs))
+		}
+		root := excs[2]
+		if !root.Mechanism.IsExceptionGroup || root.Mechanism.ExceptionID != 0 {
+			t.Errorf("expected root to be exception group %+v", root.Mechanism)
+		}
+		for _, exc := range excs[:2] {
+			if *exc.Mechanism.ParentID != 0 || exc.Mechanism.IsExceptionGroup {
+				t.Errorf("unexpected mechanism %+v", exc.Mechanism)
+			}
+		}
+	})
+
+	t.Run("JSON encoding", func(t *testing.T) {
+		ev := NewEvent(fmt.Errorf("outer: %w", io.EOF), EventOptions{})
+		b, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []string{"event_id", "timestamp", "platform", "level", "exception", "fingerprint"} {
+			if _, ok := m[k]; !ok {
+				t.Errorf("expected %q in encoded event %s", k, b)
+			}
+		}
+		if _, ok := m["tags"]; ok {
+			t.Errorf("unexpected tags in encoded event %s", b)
+		}
+	})
+
+	t.Run("field values which can't be encoded", func(t *testing.T) {
+		ev := NewEvent(exerr.New("some error").AddField("nan", math.NaN()).AddField("fn", func() {}).AddField("id", 42), EventOptions{})
+		b, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatalf("encoding event: %v", err)
+		}
+		var m struct{ Extra map[string]any }
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		if v := m.Extra["nan"]; v != "NaN" {
+			t.Errorf("expected NaN to be reported as string, got %v", v)
+		}
+		if v, ok := m.Extra["fn"].(string); !ok || !strings.HasPrefix(v, "0x") {
+			t.Errorf("expected func to be reported as string, got %v", m.Extra["fn"])
+		}
+		if v := m.Extra["id"]; v != 42.0 {
+			t.Errorf("expected id to be reported as is, got %v", v)
+		}
+	})
+}
+
+func Test_isInApp(t *testing.T) {
+	t.Parallel()
+
+	prefixes := []string{"example.com/app", "example.com/lib/"}
+	testCases := []struct {
+		pkg   string
+		inApp bool
+	}{
+		{pkg: "example.com/app", inApp: true},
+		{pkg: "example.com/app/sub", inApp: true},
+		{pkg: "example.com/application", inApp: false},
+		{pkg: "example.com/lib/x", inApp: true},
+		{pkg: "runtime", inApp: false},
+	}
+	for _, tc := range testCases {
+		if v := isInApp(tc.pkg, prefixes); v != tc.inApp {
+			t.Errorf("%s: expected %t, got %t", tc.pkg, tc.inApp, v)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package exerr
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+/*
+LogAttrs returns attributes describing the error "err" for logging with [log/slog]:
+
+  - "error" - the error message;
+  - "fields" - group of the fields of the error (see [Fields]), sorted by name;
+  - "stack" - the stack trace of the error (see [Stack]).
+
+Attributes which would be empty are omitted, nil is returned for nil error. Usage:
+
+	logger.LogAttrs(ctx, slog.LevelError, "request failed", exerr.LogAttrs(err)...)
+*/
+func LogAttrs(err error) []slog.Attr {
+	if err == nil {
+		return nil
+	}
+	attrs := []slog.Attr{slog.String("error", err.Error())}
+	if fields := Fields(err); len(fields) != 0 {
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		slices.Sort(names)
+		group := make([]any, 0, len(names))
+		for _, k := range names {
+			group = append(group, slog.Any(k, fields[k]))
+		}
+		attrs = append(attrs, slog.Group("fields", group...))
+	}
+	if stack := Stack(err); len(stack) != 0 {
+		attrs = append(attrs, slog.Any("stack", stack))
+	}
+	return attrs
+}
+
+// LoggedPolicy determines how [NewLogHandler] handles errors which have already been logged.
+type LoggedPolicy int
+
+const (
+	LoggedKeep      LoggedPolicy = iota // log the record as usual
+	LoggedSkip                          // drop the record
+	LoggedDowngrade                     // log the record with HandlerOptions.DowngradeLevel
+)
+
+// HandlerOptions configures the handler returned by [NewLogHandler].
+type HandlerOptions struct {
+	// Logged is the policy for records which contain error marked as logged (see [IsLogged]).
+	Logged LoggedPolicy
+
+	// DowngradeLevel is the level of the record when policy is LoggedDowngrade.
+	// Default (zero value) is slog.LevelInfo.
+	DowngradeLevel slog.Level
+
+	// MarkLogged makes the handler to mark errors of the records it has logged as
+	// logged (see [MarkLogged]). Only errors which have exerr error in the chain
+	// can be marked.
+	MarkLogged bool
+}
+
+/*
+NewLogHandler returns [slog.Handler] which passes records to "next", handling the
+attributes of the records which are errors according to the options. Errors in the
+attributes added with Logger.With are taken into account too.
+
+	logger := slog.New(exerr.NewLogHandler(h, &exerr.HandlerOptions{Logged: exerr.LoggedSkip, MarkLogged: true}))
+*/
+func NewLogHandler(next slog.Handler, opts *HandlerOptions) slog.Handler {
+	h := &logHandler{next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+type logHandler struct {
+	next slog.Handler
+	opts HandlerOptions
+	errs []error // errors in the attributes added with WithAttrs
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	// clip so that concurrent calls don't append into the shared backing array
+	errs := slices.Clip(h.errs)
+	r.Attrs(func(a slog.Attr) bool {
+		errs = appendErrors(errs, a)
+		return true
+	})
+
+	if h.opts.Logged != LoggedKeep && slices.ContainsFunc(errs, IsLogged) {
+		if h.opts.Logged == LoggedSkip {
+			return nil
+		}
+		if !h.next.Enabled(ctx, h.opts.DowngradeLevel) {
+			return nil
+		}
+		r.Level = h.opts.DowngradeLevel
+	}
+
+	err := h.next.Handle(ctx, r)
+	if err == nil && h.opts.MarkLogged {
+		for _, e := range errs {
+			MarkLogged(e)
+		}
+	}
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	errs := slices.Clone(h.errs)
+	for _, a := range attrs {
+		errs = appendErrors(errs, a)
+	}
+	return &logHandler{next: h.next.WithAttrs(attrs), opts: h.opts, errs: errs}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{next: h.next.WithGroup(name), opts: h.opts, errs: h.errs}
+}
+
+// appendErrors appends errors in the attribute "a" (and it's subgroups) to "errs".
+func appendErrors(errs []error, a slog.Attr) []error {
+	switch v := a.Value.Resolve(); v.Kind() {
+	case slog.KindGroup:
+		for _, ga := range v.Group() {
+			errs = appendErrors(errs, ga)
+		}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok && err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,148 @@
+package exerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func Test_LogAttrs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if attrs := LogAttrs(nil); attrs != nil {
+			t.Errorf("expected nil, got %v", attrs)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		attrs := LogAttrs(errors.New("plain"))
+		if len(attrs) != 1 || attrs[0].Key != "error" || attrs[0].Value.String() != "plain" {
+			t.Errorf("unexpected attributes %v", attrs)
+		}
+	})
+
+	t.Run("exerr error", func(t *testing.T) {
+		err := New("failed").AddField("b", 2).AddField("a", "x")
+		buf := &bytes.Buffer{}
+		slog.New(slog.NewJSONHandler(buf, nil)).LogAttrs(context.Background(), slog.LevelError, "msg", LogAttrs(err)...)
+
+		var rec struct {
+			Error  string
+			Fields map[string]any
+			Stack  []string
+		}
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Error != "failed" {
+			t.Errorf("unexpected message %q", rec.Error)
+		}
+		if len(rec.Fields) != 2 || rec.Fields["a"] != "x" || rec.Fields["b"] != 2.0 {
+			t.Errorf("unexpected fields %v", rec.Fields)
+		}
+		if len(rec.Stack) == 0 {
+			t.Error("expected stack trace")
+		}
+
+		attrs := LogAttrs(err)
+		if g := attrs[1].Value.Group(); len(g) != 2 || g[0].Key != "a" || g[1].Key != "b" {
+			t.Errorf("expected fields to be sorted by name, got %v", g)
+		}
+	})
+}
+
+func Test_NewLogHandler(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		Level string
+		Msg   string
+	}
+	newLogger := func(opts *HandlerOptions) (*slog.Logger, func() []record) {
+		buf := &bytes.Buffer{}
+		h := NewLogHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}), opts)
+		return slog.New(h), func() (r []record) {
+			dec := json.NewDecoder(buf)
+			for dec.More() {
+				var rec record
+				if err := dec.Decode(&rec); err != nil {
+					t.Fatal(err)
+				}
+				r = append(r, rec)
+			}
+			return r
+		}
+	}
+
+	t.Run("default options", func(t *testing.T) {
+		logger, records := newLogger(nil)
+		err := MarkLogged(New("failed"))
+		logger.Error("first", "err", err)
+		logger.Info("no error")
+		if r := records(); len(r) != 2 || r[0].Level != "ERROR" {
+			t.Errorf("unexpected records %v", r)
+		}
+	})
+
+	t.Run("mark and skip", func(t *testing.T) {
+		logger, records := newLogger(&HandlerOptions{Logged: LoggedSkip, MarkLogged: true})
+		err := New("failed")
+		logger.Error("first", "err", err)
+		if !IsLogged(err) {
+			t.Error("expected error to be marked as logged")
+		}
+		logger.Error("second", "err", fmt.Errorf("wrapped: %w", err))
+		logger.With("err", err).Error("third")
+		logger.Error("fourth", slog.Group("g", slog.Any("err", err)))
+		logger.Error("other", "err", errors.New("other"))
+		if r := records(); len(r) != 2 || r[0].Msg != "first" || r[1].Msg != "other" {
+			t.Errorf("unexpected records %v", r)
+		}
+	})
+
+	t.Run("downgrade", func(t *testing.T) {
+		logger, records := newLogger(&HandlerOptions{Logged: LoggedDowngrade, DowngradeLevel: slog.LevelDebug})
+		logger.WithGroup("g").Error("first", "err", MarkLogged(io.EOF))
+		r := records()
+		if len(r) != 1 || r[0].Level != "DEBUG" {
+			t.Errorf("unexpected records %v", r)
+		}
+
+		// downgraded level is not enabled
+		buf := &bytes.Buffer{}
+		logger = slog.New(NewLogHandler(slog.NewJSONHandler(buf, nil), &HandlerOptions{Logged: LoggedDowngrade, DowngradeLevel: slog.LevelDebug}))
+		logger.Error("first", "err", MarkLogged(io.EOF))
+		if buf.Len() != 0 {
+			t.Errorf("unexpected output %s", buf)
+		}
+	})
+	t.Run("concurrent use", func(t *testing.T) {
+		// errors added by With leave spare capacity in the slice of the handler
+		logger := slog.New(NewLogHandler(slog.NewJSONHandler(io.Discard, nil), &HandlerOptions{Logged: LoggedSkip, MarkLogged: true}))
+		logger = logger.With("e1", io.EOF, "e2", io.ErrUnexpectedEOF, "e3", io.ErrClosedPipe)
+
+		errs := make([]error, 8)
+		var wg sync.WaitGroup
+		for i := range errs {
+			errs[i] = New("failed")
+			wg.Add(1)
+			go func(err error) {
+				defer wg.Done()
+				logger.Error("failed", "err", err)
+			}(errs[i])
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if !IsLogged(err) {
+				t.Errorf("expected error %d to be marked as logged", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package sqlerr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+/*
+wConn wraps driver.Conn and implements all the optional interfaces, when the wrapped
+connection doesn't implement the interface the method behaves as if it wasn't
+implemented (ie returns driver.ErrSkip) or falls back to the non-context version
+like database/sql does.
+*/
+type wConn struct {
+	c   driver.Conn
+	cfg *config
+}
+
+var (
+	_ driver.Conn               = (*wConn)(nil)
+	_ driver.ConnPrepareContext = (*wConn)(nil)
+	_ driver.ConnBeginTx        = (*wConn)(nil)
+	_ driver.ExecerContext      = (*wConn)(nil)
+	_ driver.QueryerContext     = (*wConn)(nil)
+	_ driver.Pinger             = (*wConn)(nil)
+	_ driver.SessionResetter    = (*wConn)(nil)
+	_ driver.Validator          = (*wConn)(nil)
+	_ driver.NamedValueChecker  = (*wConn)(nil)
+)
+
+func (c *wConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var st driver.Stmt
+	var err error
+	if cp, ok := c.c.(driver.ConnPrepareContext); ok {
+		st, err = cp.PrepareContext(ctx, query)
+	} else if st, err = c.c.Prepare(query); err == nil && ctx.Err() != nil {
+		st.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, c.cfg.annotate(err, "prepare", query, nil, start)
+	}
+	return &wStmt{s: st, conn: c, query: query}, nil
+}
+
+func (c *wConn) Close() error { return c.c.Close() }
+
+func (c *wConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *wConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var tx driver.Tx
+	var err error
+	if cb, ok := c.c.(driver.ConnBeginTx); ok {
+		tx, err = cb.BeginTx(ctx, opts)
+	} else {
+		switch {
+		case opts.Isolation != 0:
+			err = errors.New("sql: driver does not support non-default isolation level")
+		case opts.ReadOnly:
+			err = errors.New("sql: driver does not support read-only transactions")
+		default:
+			if tx, err = c.c.Begin(); err == nil && ctx.Err() != nil {
+				tx.Rollback()
+				err = ctx.Err()
+			}
+		}
+	}
+	if err != nil {
+		return nil, c.cfg.annotate(err, "begin", "", nil, start)
+	}
+	return &wTx{t: tx, cfg: c.cfg}, nil
+}
+
+func (c *wConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var r driver.Result
+	var err error
+	switch ec := c.c.(type) {
+	case driver.ExecerContext:
+		r, err = ec.ExecContext(ctx, query, args)
+	case driver.Execer: //nolint:staticcheck // fallback for legacy drivers
+		var values []driver.Value
+		if values, err = namedValueToValue(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				r, err = ec.Exec(query, values)
+			}
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	return r, c.cfg.annotate(err, "exec", query, args, start)
+}
+
+func (c *wConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var r driver.Rows
+	var err error
+	switch qc := c.c.(type) {
+	case driver.QueryerContext:
+		r, err = qc.QueryContext(ctx, query, args)
+	case driver.Queryer: //nolint:staticcheck // fallback for legacy drivers
+		var values []driver.Value
+		if values, err = namedValueToValue(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				r, err = qc.Query(query, values)
+			}
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	return r, c.cfg.annotate(err, "query", query, args, start)
+}
+
+func (c *wConn) Ping(ctx context.Context) error {
+	if p, ok := c.c.(driver.Pinger); ok {
+		start := time.Now()
+		return c.cfg.annotate(p.Ping(ctx), "ping", "", nil, start)
+	}
+	return nil
+}
+
+func (c *wConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.c.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *wConn) IsValid() bool {
+	if v, ok := c.c.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *wConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.c.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type wStmt struct {
+	s     driver.Stmt
+	conn  *wConn
+	query string
+}
+
+var (
+	_ driver.Stmt              = (*wStmt)(nil)
+	_ driver.StmtExecContext   = (*wStmt)(nil)
+	_ driver.StmtQueryContext  = (*wStmt)(nil)
+	_ driver.NamedValueChecker = (*wStmt)(nil)
+)
+
+func (s *wStmt) Close() error { return s.s.Close() }
+
+func (s *wStmt) NumInput() int { return s.s.NumInput() }
+
+func (s *wStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	r, err := s.s.Exec(args) //nolint:staticcheck // must forward the deprecated method
+	return r, s.conn.cfg.annotate(err, "exec", s.query, valueToNamedValue(args), start)
+}
+
+func (s *wStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	r, err := s.s.Query(args) //nolint:staticcheck // must forward the deprecated method
+	return r, s.conn.cfg.annotate(err, "query", s.query, valueToNamedValue(args), start)
+}
+
+func (s *wStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var r driver.Result
+	var err error
+	if sc, ok := s.s.(driver.StmtExecContext); ok {
+		r, err = sc.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValueToValue(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				r, err = s.s.Exec(values) //nolint:staticcheck // fallback for legacy drivers
+			}
+		}
+	}
+	return r, s.conn.cfg.annotate(err, "exec", s.query, args, start)
+}
+
+func (s *wStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var r driver.Rows
+	var err error
+	if sc, ok := s.s.(driver.StmtQueryContext); ok {
+		r, err = sc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValueToValue(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				r, err = s.s.Query(values) //nolint:staticcheck // fallback for legacy drivers
+			}
+		}
+	}
+	return r, s.conn.cfg.annotate(err, "query", s.query, args, start)
+}
+
+/*
+CheckNamedValue forwards to the statement or the connection (database/sql consults
+the connection only when the statement doesn't implement the interface).
+*/
+func (s *wStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.s.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+type wTx struct {
+	t   driver.Tx
+	cfg *config
+}
+
+func (t *wTx) Commit() error {
+	start := time.Now()
+	return t.cfg.annotate(t.t.Commit(), "commit", "", nil, start)
+}
+
+func (t *wTx) Rollback() error {
+	start := time.Now()
+	return t.cfg.annotate(t.t.Rollback(), "rollback", "", nil, start)
+}
+
+func namedValueToValue(named []driver.NamedValue) ([]driver.Value, error) {
+	r := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		r[i] = nv.Value
+	}
+	return r, nil
+}
+
+func valueToNamedValue(args []driver.Value) []driver.NamedValue {
+	r := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		r[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return r
+}
@@ -0,0 +1,151 @@
+package sqlerr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+)
+
+/*
+In-memory fake driver for tests. Operations fail when the statement contains word
+"fail", prepare fails when the statement contains "syntax" and queries return single
+row containing the arguments of the query.
+*/
+
+var errFake = errors.New("fake error")
+
+type fakeDriver struct {
+	legacy bool // conns don't implement the optional context interfaces
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	if name == "fail" {
+		return nil, errFake
+	}
+	if d.legacy {
+		return &legacyConn{}, nil
+	}
+	return &fakeConn{failCommit: name == "failcommit"}, nil
+}
+
+// fakeContextDriver implements driver.DriverContext
+type fakeContextDriver struct{ fakeDriver }
+
+func (d *fakeContextDriver) OpenConnector(name string) (driver.Connector, error) {
+	if name == "fail" {
+		return nil, errFake
+	}
+	return &fakeConnector{d: d, name: name}, nil
+}
+
+type fakeConnector struct {
+	d      driver.Driver
+	name   string
+	closed bool
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.d.Open(c.name) }
+
+func (c *fakeConnector) Driver() driver.Driver { return c.d }
+
+func (c *fakeConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+// legacyConn implements only the required methods of the driver.Conn.
+type legacyConn struct{}
+
+func (c *legacyConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "syntax") {
+		return nil, errFake
+	}
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *legacyConn) Close() error { return nil }
+
+func (c *legacyConn) Begin() (driver.Tx, error) { return &fakeTx{}, nil }
+
+type fakeConn struct {
+	legacyConn
+	failCommit bool
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		return nil, errFake
+	}
+	return &fakeTx{fail: c.failCommit}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errFake
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errFake
+	}
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return &fakeRows{values: values}, nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error { return errFake }
+
+type fakeStmt struct{ query string }
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errFake
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errFake
+	}
+	return &fakeRows{values: args}, nil
+}
+
+type fakeTx struct{ fail bool }
+
+func (tx *fakeTx) Commit() error {
+	if tx.fail {
+		return errFake
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string { return make([]string, len(r.values)) }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
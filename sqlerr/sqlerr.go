@@ -0,0 +1,182 @@
+/*
+Package sqlerr wraps [database/sql/driver] drivers so that errors returned by the
+database operations become exerr errors with the statement, it's arguments, the
+operation and the elapsed time attached as fields.
+
+To use it wrap the driver (or connector) of the database:
+
+	db := sql.OpenDB(sqlerr.WrapConnector(connector))
+
+or register wrapped driver under new name:
+
+	sql.Register("postgres-exerr", sqlerr.WrapDriver(&pq.Driver{}))
+
+Arguments of the statement may contain sensitive data, use [RedactArgs] or
+[WithArgsRedactor] to control what ends up in the error.
+
+Errors database/sql uses for signaling (like [driver.ErrSkip]) are returned as is.
+Legacy [driver.ColumnConverter] interface of the statements is not forwarded.
+*/
+package sqlerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+// Keys of the fields attached to the errors.
+var (
+	Op      = exerr.NewKey[string]("sql_op") // "open", "prepare", "exec", "query", "begin", "commit",...
+	Query   = exerr.NewKey[string]("sql_query")
+	Args    = exerr.NewKey[[]any]("sql_args")
+	Elapsed = exerr.NewKey[time.Duration]("sql_elapsed")
+)
+
+type config struct {
+	redact func(query string, args []any) []any
+}
+
+// Option configures the wrapper, see [WrapDriver] and [WrapConnector].
+type Option func(*config)
+
+/*
+WithArgsRedactor sets function which is called with the statement and it's arguments
+before the arguments are attached to the error, the return value is used as the value
+of the [Args] field. When the function returns nil the field is not added.
+*/
+func WithArgsRedactor(fn func(query string, args []any) []any) Option {
+	return func(c *config) { c.redact = fn }
+}
+
+/*
+RedactArgs replaces values of all the statement arguments with the string "[REDACTED]"
+(so that the number of arguments is still visible).
+*/
+func RedactArgs() Option {
+	return WithArgsRedactor(func(_ string, args []any) []any {
+		r := make([]any, len(args))
+		for i := range r {
+			r[i] = "[REDACTED]"
+		}
+		return r
+	})
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+/*
+annotate attaches fields to the error "err" returned by operation "op" started at
+"start". nil and the errors used by database/sql for signaling are returned as is.
+*/
+func (c *config) annotate(err error, op, query string, args []driver.NamedValue, start time.Time) error {
+	if err == nil || err == driver.ErrSkip || err == driver.ErrRemoveArgument {
+		return err
+	}
+	e := exerr.With(err, Op, op)
+	e = exerr.With(e, Elapsed, time.Since(start))
+	if query != "" {
+		e = exerr.With(e, Query, query)
+	}
+	if len(args) != 0 {
+		values := make([]any, len(args))
+		for i, a := range args {
+			if a.Name != "" {
+				values[i] = sql.Named(a.Name, a.Value)
+			} else {
+				values[i] = a.Value
+			}
+		}
+		if c.redact != nil {
+			values = c.redact(query, values)
+		}
+		if values != nil {
+			e = exerr.With(e, Args, values)
+		}
+	}
+	return e
+}
+
+/*
+WrapDriver returns driver which returns errors of the driver "d" as exerr errors.
+*/
+func WrapDriver(d driver.Driver, opts ...Option) driver.Driver {
+	return wrapDriver(d, newConfig(opts))
+}
+
+func wrapDriver(d driver.Driver, cfg *config) driver.Driver {
+	w := &wDriver{d: d, cfg: cfg}
+	if _, ok := d.(driver.DriverContext); ok {
+		return wDriverContext{w}
+	}
+	return w
+}
+
+/*
+WrapConnector returns connector which returns errors of the connector "c" (and the
+connections it creates) as exerr errors.
+*/
+func WrapConnector(c driver.Connector, opts ...Option) driver.Connector {
+	cfg := newConfig(opts)
+	return &wConnector{c: c, d: wrapDriver(c.Driver(), cfg), cfg: cfg}
+}
+
+type wDriver struct {
+	d   driver.Driver
+	cfg *config
+}
+
+func (d *wDriver) Open(name string) (driver.Conn, error) {
+	start := time.Now()
+	c, err := d.d.Open(name)
+	if err != nil {
+		return nil, d.cfg.annotate(err, "open", "", nil, start)
+	}
+	return &wConn{c: c, cfg: d.cfg}, nil
+}
+
+// wDriverContext is the wrapper of the driver which implements driver.DriverContext.
+type wDriverContext struct{ *wDriver }
+
+func (d wDriverContext) OpenConnector(name string) (driver.Connector, error) {
+	start := time.Now()
+	c, err := d.d.(driver.DriverContext).OpenConnector(name)
+	if err != nil {
+		return nil, d.cfg.annotate(err, "open", "", nil, start)
+	}
+	return &wConnector{c: c, d: d, cfg: d.cfg}, nil
+}
+
+type wConnector struct {
+	c   driver.Connector
+	d   driver.Driver // wrapped driver
+	cfg *config
+}
+
+func (c *wConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	start := time.Now()
+	conn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, c.cfg.annotate(err, "connect", "", nil, start)
+	}
+	return &wConn{c: conn, cfg: c.cfg}, nil
+}
+
+func (c *wConnector) Driver() driver.Driver { return c.d }
+
+func (c *wConnector) Close() error {
+	if cl, ok := c.c.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package sqlerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/exerr"
+)
+
+func expectFields(t *testing.T, err error, op, query string, args []any) {
+	t.Helper()
+	if !errors.Is(err, errFake) {
+		t.Fatalf("expected fake error, got %v", err)
+	}
+	if v, _ := Op.From(err); v != op {
+		t.Errorf("expected op %q, got %q", op, v)
+	}
+	if v, _ := Query.From(err); v != query {
+		t.Errorf("expected query %q, got %q", query, v)
+	}
+	v, ok := Args.From(err)
+	if len(v) != len(args) || (args == nil && ok) {
+		t.Errorf("expected args %v, got %v", args, v)
+	}
+	for i := range args {
+		if i < len(v) && v[i] != args[i] {
+			t.Errorf("expected args %v, got %v", args, v)
+		}
+	}
+	if _, ok := Elapsed.From(err); !ok {
+		t.Error("expected elapsed time field")
+	}
+	if len(exerr.Frames(err)) == 0 {
+		t.Error("expected error to have stack trace")
+	}
+}
+
+func Test_WrapConnector(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}}))
+	defer db.Close()
+
+	t.Run("success", func(t *testing.T) {
+		if _, err := db.ExecContext(ctx, "insert", 1); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		var a, b string
+		if err := db.QueryRowContext(ctx, "select", "x", "y").Scan(&a, &b); err != nil || a != "x" || b != "y" {
+			t.Errorf("unexpected result %q, %q, error: %v", a, b, err)
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, "insert fail", 1, "foo")
+		expectFields(t, err, "exec", "insert fail", []any{int64(1), "foo"})
+	})
+
+	t.Run("query", func(t *testing.T) {
+		_, err := db.QueryContext(ctx, "select fail", sql.Named("id", 1))
+		expectFields(t, err, "query", "select fail", []any{sql.Named("id", int64(1))})
+	})
+
+	t.Run("prepare", func(t *testing.T) {
+		_, err := db.PrepareContext(ctx, "syntax error")
+		expectFields(t, err, "prepare", "syntax error", nil)
+	})
+
+	t.Run("prepared statement", func(t *testing.T) {
+		stmt, err := db.PrepareContext(ctx, "update fail")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		_, err = stmt.ExecContext(ctx, 42)
+		expectFields(t, err, "exec", "update fail", []any{int64(42)})
+		_, err = stmt.QueryContext(ctx, 42)
+		expectFields(t, err, "query", "update fail", []any{int64(42)})
+	})
+
+	t.Run("begin", func(t *testing.T) {
+		_, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		expectFields(t, err, "begin", "", nil)
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		expectFields(t, db.PingContext(ctx), "ping", "", nil)
+	})
+}
+
+func Test_Origin(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}}))
+	defer db.Close()
+
+	_, errExec := db.ExecContext(ctx, "insert fail", 1)
+	_, errQuery := db.QueryContext(ctx, "select fail")
+	for _, err := range []error{errExec, errQuery} {
+		f, ok := exerr.Origin(err)
+		if !ok {
+			t.Fatalf("expected origin of %v to be found", err)
+		}
+		if f.Function != "github.com/ainvaltin/exerr/sqlerr.Test_Origin" {
+			t.Errorf("expected origin to be the caller of database/sql, got %v", f)
+		}
+	}
+}
+
+func Test_WrapConnector_commit(t *testing.T) {
+	t.Parallel()
+
+	db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}, name: "failcommit"}))
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFields(t, tx.Commit(), "commit", "", nil)
+}
+
+func Test_WrapConnector_close(t *testing.T) {
+	t.Parallel()
+
+	c := &fakeConnector{d: &fakeDriver{}}
+	db := sql.OpenDB(WrapConnector(c))
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.closed {
+		t.Error("expected wrapped connector to be closed")
+	}
+}
+
+func Test_WrapDriver(t *testing.T) {
+	t.Parallel()
+
+	t.Run("legacy driver", func(t *testing.T) {
+		// the connection doesn't implement ExecerContext so database/sql falls back to
+		// preparing the statement, the error must come from the statement
+		sql.Register("sqlerr-legacy", WrapDriver(&fakeDriver{legacy: true}))
+		db, err := sql.Open("sqlerr-legacy", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		_, err = db.Exec("insert fail", 1)
+		expectFields(t, err, "exec", "insert fail", []any{int64(1)})
+		_, err = db.Query("select fail")
+		expectFields(t, err, "query", "select fail", nil)
+
+		var s string
+		if err := db.QueryRow("select", "x").Scan(&s); err != nil || s != "x" {
+			t.Errorf("unexpected result %q, error: %v", s, err)
+		}
+		if err := db.Ping(); err != nil {
+			t.Errorf("unexpected ping error: %v", err)
+		}
+		if _, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true}); err == nil {
+			t.Error("expected error for read-only transaction")
+		}
+	})
+
+	t.Run("open fails", func(t *testing.T) {
+		sql.Register("sqlerr-open", WrapDriver(&fakeDriver{}))
+		db, err := sql.Open("sqlerr-open", "fail")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		expectFields(t, db.Ping(), "open", "", nil)
+	})
+
+	t.Run("driver context", func(t *testing.T) {
+		d := WrapDriver(&fakeContextDriver{})
+		if _, ok := d.(driver.DriverContext); !ok {
+			t.Fatal("expected wrapper to implement DriverContext")
+		}
+		if _, ok := WrapDriver(&fakeDriver{}).(driver.DriverContext); ok {
+			t.Fatal("expected wrapper not to implement DriverContext")
+		}
+
+		sql.Register("sqlerr-ctx", d)
+		if _, err := sql.Open("sqlerr-ctx", "fail"); err != nil {
+			expectFields(t, err, "open", "", nil)
+		} else {
+			t.Error("expected error opening connector")
+		}
+
+		db, err := sql.Open("sqlerr-ctx", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if db.Driver() != d {
+			t.Error("expected connector to return the wrapping driver")
+		}
+		_, err = db.Exec("insert fail")
+		expectFields(t, err, "exec", "insert fail", nil)
+	})
+}
+
+func Test_redaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RedactArgs", func(t *testing.T) {
+		db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}}, RedactArgs()))
+		defer db.Close()
+		_, err := db.Exec("insert fail", "secret", 2)
+		expectFields(t, err, "exec", "insert fail", []any{"[REDACTED]", "[REDACTED]"})
+	})
+
+	t.Run("WithArgsRedactor", func(t *testing.T) {
+		db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}}, WithArgsRedactor(func(query string, args []any) []any {
+			if query == "insert fail" {
+				return nil
+			}
+			return args
+		})))
+		defer db.Close()
+		_, err := db.Exec("insert fail", "secret")
+		expectFields(t, err, "exec", "insert fail", nil)
+	})
+}
+
+func Test_annotate(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig(nil)
+	for _, err := range []error{nil, driver.ErrSkip, driver.ErrRemoveArgument} {
+		if e := cfg.annotate(err, "exec", "q", nil, time.Now()); e != err {
+			t.Errorf("expected %v to be returned as is, got %v", err, e)
+		}
+	}
+	err := cfg.annotate(driver.ErrBadConn, "exec", "q", nil, time.Now())
+	if !errors.Is(err, driver.ErrBadConn) {
+		t.Error("expected annotated error to match ErrBadConn")
+	}
+	if err.Error() != driver.ErrBadConn.Error() {
+		t.Errorf("expected message not to change, got %q", err.Error())
+	}
+}
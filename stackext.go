@@ -0,0 +1,116 @@
+package exerr
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+/*
+StackExtractor returns program counters (as returned by [runtime.Callers]) of the
+stack trace carried by error "err", ok is false when "err" doesn't have stack trace
+(the extractor doesn't recognize the error). Only the error itself should be checked,
+not the errors it wraps.
+*/
+type StackExtractor func(err error) (pcs []uintptr, ok bool)
+
+var stackExtractors atomic.Pointer[[]StackExtractor]
+
+/*
+RegisterStackExtractor registers function which extracts stack trace from errors not
+created by this package. Registered extractors are consulted (in the order of the
+registration) before the built-in ones.
+
+Out of the box stack traces of the errors which have one of the following methods
+are recognized:
+
+  - PC() []uintptr (errors of this package);
+  - Callers() []uintptr (ie github.com/go-errors/errors);
+  - StackTrace() returning slice of uintptr based frames (ie github.com/pkg/errors,
+    github.com/cockroachdb/errors);
+  - StackFrames() returning slice of structs with ProgramCounter uintptr field (ie
+    github.com/go-errors/errors).
+
+Meant to be called during program initialization, ie from init func.
+*/
+func RegisterStackExtractor(fn StackExtractor) {
+	for {
+		old := stackExtractors.Load()
+		var fns []StackExtractor
+		if old != nil {
+			fns = append(fns, *old...)
+		}
+		fns = append(fns, fn)
+		if stackExtractors.CompareAndSwap(old, &fns) {
+			return
+		}
+	}
+}
+
+/*
+stackOf returns program counters of the stack trace carried by "err" (not looking
+into the errors it wraps).
+*/
+func stackOf(err error) ([]uintptr, bool) {
+	if fns := stackExtractors.Load(); fns != nil {
+		for _, fn := range *fns {
+			if pcs, ok := fn(err); ok {
+				return pcs, true
+			}
+		}
+	}
+
+	switch e := err.(type) {
+	case stacked:
+		return e.PC(), true
+	case interface{ Callers() []uintptr }:
+		return e.Callers(), true
+	}
+	return reflectStack(err)
+}
+
+var uintptrType = reflect.TypeFor[uintptr]()
+
+/*
+reflectStack extracts stack trace from the errors which have StackTrace or StackFrames
+method returning types declared in the package of the error library (so they can't be
+type asserted without importing the library).
+*/
+func reflectStack(err error) ([]uintptr, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, false
+	}
+
+	// StackTrace() []Frame where Frame is uintptr
+	if m := v.MethodByName("StackTrace"); m.IsValid() && isSliceGetter(m.Type()) {
+		if st := m.Type().Out(0); st.Elem().Kind() == reflect.Uintptr {
+			s := m.Call(nil)[0]
+			pcs := make([]uintptr, s.Len())
+			for i := range pcs {
+				pcs[i] = uintptr(s.Index(i).Uint())
+			}
+			return pcs, true
+		}
+	}
+
+	// StackFrames() []StackFrame where StackFrame is struct with ProgramCounter field
+	if m := v.MethodByName("StackFrames"); m.IsValid() && isSliceGetter(m.Type()) {
+		st := m.Type().Out(0).Elem()
+		if st.Kind() == reflect.Struct {
+			if f, ok := st.FieldByName("ProgramCounter"); ok && f.Type == uintptrType {
+				s := m.Call(nil)[0]
+				pcs := make([]uintptr, s.Len())
+				for i := range pcs {
+					pcs[i] = uintptr(s.Index(i).FieldByIndex(f.Index).Uint())
+				}
+				return pcs, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isSliceGetter returns true when "mt" is type of method without arguments returning single slice.
+func isSliceGetter(mt reflect.Type) bool {
+	return mt.NumIn() == 0 && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Slice
+}
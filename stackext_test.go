@@ -0,0 +1,140 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// types mimicking errors of the popular error libraries
+
+// pkg/errors style: StackTrace() returns slice of uintptr based frames
+type pkgFrame uintptr
+
+type pkgStackError struct{ pcs []uintptr }
+
+func (e *pkgStackError) Error() string { return "pkg error" }
+
+func (e *pkgStackError) StackTrace() []pkgFrame {
+	st := make([]pkgFrame, len(e.pcs))
+	for i, pc := range e.pcs {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+// go-errors style: Callers() and StackFrames()
+type goStackFrame struct {
+	File           string
+	ProgramCounter uintptr
+}
+
+type goFramesError struct{ pcs []uintptr }
+
+func (e *goFramesError) Error() string { return "go error" }
+
+func (e *goFramesError) StackFrames() []goStackFrame {
+	r := make([]goStackFrame, len(e.pcs))
+	for i, pc := range e.pcs {
+		r[i] = goStackFrame{File: "x.go", ProgramCounter: pc}
+	}
+	return r
+}
+
+type goCallersError struct{ pcs []uintptr }
+
+func (e *goCallersError) Error() string { return "go error" }
+
+func (e *goCallersError) Callers() []uintptr { return e.pcs }
+
+// StackTrace returning something else than frames must be ignored
+type stringStackError struct{}
+
+func (stringStackError) Error() string { return "string stack" }
+
+func (stringStackError) StackTrace() []string { return []string{"foo"} }
+
+func pcsHere() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+func Test_foreign_stacks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		new  func(pcs []uintptr) error
+	}{
+		{name: "StackTrace", new: func(pcs []uintptr) error { return &pkgStackError{pcs: pcs} }},
+		{name: "StackFrames", new: func(pcs []uintptr) error { return &goFramesError{pcs: pcs} }},
+		{name: "Callers", new: func(pcs []uintptr) error { return &goCallersError{pcs: pcs} }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", tc.new(pcsHere()))
+			frames := Frames(err)
+			if len(frames) == 0 {
+				t.Fatal("expected stack trace to be found")
+			}
+			if fn := frames[0].Function; !strings.Contains(fn, ".Test_foreign_stacks.") {
+				t.Errorf("unexpected function of the first frame: %s", fn)
+			}
+			if s := Stack(err); len(s) != len(frames) {
+				t.Errorf("expected %d lines of stack, got %d", len(frames), len(s))
+			}
+		})
+	}
+
+	t.Run("innermost stack is used", func(t *testing.T) {
+		inner := &pkgStackError{pcs: pcsHere()}
+		err := Wrap(inner, "outer")
+		if pcs := stackPC(err); len(pcs) == 0 || pcs[0] != inner.pcs[0] {
+			t.Error("expected stack of the inner error to be used")
+		}
+	})
+
+	t.Run("WithStack reuses foreign stack", func(t *testing.T) {
+		inner := &goCallersError{pcs: pcsHere()}
+		err := WithStack(inner)
+		if pcs := err.(stacked).PC(); len(pcs) == 0 || pcs[0] != inner.pcs[0] {
+			t.Error("expected stack of the foreign error to be reused")
+		}
+	})
+
+	t.Run("unsupported shapes", func(t *testing.T) {
+		for _, err := range []error{stringStackError{}, errors.New("std"), (*pkgStackError)(nil)} {
+			if pcs, ok := stackOf(err); ok || pcs != nil {
+				t.Errorf("expected no stack for %T, got %v", err, pcs)
+			}
+		}
+	})
+}
+
+func Test_RegisterStackExtractor(t *testing.T) {
+	old := stackExtractors.Load()
+	t.Cleanup(func() { stackExtractors.Store(old) })
+
+	pcs := pcsHere()
+	RegisterStackExtractor(func(err error) ([]uintptr, bool) {
+		if err.Error() == "custom" {
+			return pcs, true
+		}
+		return nil, false
+	})
+
+	err := fmt.Errorf("wrapped: %w", errors.New("custom"))
+	if got := stackPC(err); len(got) != len(pcs) || got[0] != pcs[0] {
+		t.Errorf("expected stack from the registered extractor, got %v", got)
+	}
+	// extractor doesn't recognize the error, built-in ones are used
+	if got := Frames(New("other")); len(got) == 0 {
+		t.Error("expected stack of exerr error")
+	}
+	if got := stackPC(errors.New("other")); got != nil {
+		t.Errorf("expected no stack, got %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package exerr
+
+import (
+	"iter"
+	"sort"
+)
+
+/*
+Walk calls "fn" for "err" and every error in it's chain (depth-first, pre-order).
+Both single error (Unwrap() error) and multi error (Unwrap() []error) chains are
+supported. The walk stops when "fn" returns false.
+
+Parameters of the "fn" are:
+  - e: the error;
+  - depth: number of unwrap steps from "err" to "e";
+  - path: index of the error at each unwrap step, for single error chains it is
+    always zero. Slice is reused between calls so it must not be retained.
+
+Errors created by this package are containers of the error they wrap, ie the error
+created by Errorf("foo: %w", err) has "err" as it's only child.
+*/
+func Walk(err error, fn func(e error, depth int, path []int) bool) {
+	if err == nil {
+		return
+	}
+	walk(err, make([]int, 0, 8), fn)
+}
+
+func walk(err error, path []int, fn func(e error, depth int, path []int) bool) bool {
+	if !fn(err, len(path), path) {
+		return false
+	}
+	for i, e := range unwrap(err) {
+		if e != nil && !walk(e, append(path, i), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrap returns direct children of the error "err".
+func unwrap(err error) []error {
+	if e, ok := err.(*exErr); ok {
+		// exErr is a container, it's children are the children of the wrapped error
+		if e == nil || e.err == nil {
+			return nil
+		}
+		if u, ok := e.err.(interface{ Unwrap() []error }); ok {
+			return u.Unwrap()
+		}
+		if c := e.Unwrap(); c != nil {
+			return []error{c}
+		}
+		return nil
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	case interface{ Unwrap() error }:
+		if e := u.Unwrap(); e != nil {
+			return []error{e}
+		}
+	}
+	return nil
+}
+
+/*
+All returns iterator over "err" and every error in it's chain, in the same order as
+[Walk]. Iterator yields depth (number of unwrap steps from "err") and the error.
+*/
+func All(err error) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		Walk(err, func(e error, depth int, _ []int) bool {
+			return yield(depth, e)
+		})
+	}
+}
+
+/*
+FieldOrigin is field together with the error it is attached to, see [FieldsWithOrigin].
+*/
+type FieldOrigin struct {
+	Name  string
+	Value any
+	Err   error // the error in the chain the field is attached to
+	Depth int   // number of unwrap steps to Err from the root error
+}
+
+/*
+FieldsWithOrigin returns all the fields in the error chain together with the error
+they are attached to. Fields are ordered by the position of the error in the chain
+(see [Walk]) and by name within the error. Unlike [Fields] all the values are
+returned when multiple errors have field with the same name.
+*/
+func FieldsWithOrigin(err error) (r []FieldOrigin) {
+	Walk(err, func(e error, depth int, _ []int) bool {
+		if fv, ok := e.(interface{ Fields() map[string]any }); ok {
+			start := len(r)
+			for k, v := range fv.Fields() {
+				r = append(r, FieldOrigin{Name: k, Value: v, Err: e, Depth: depth})
+			}
+			added := r[start:]
+			sort.Slice(added, func(i, j int) bool { return added[i].Name < added[j].Name })
+		}
+		return true
+	})
+	return r
+}
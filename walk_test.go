@@ -0,0 +1,189 @@
+package exerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type walkItem struct {
+	err   error
+	depth int
+	path  []int
+}
+
+func walkAll(err error) (r []walkItem) {
+	Walk(err, func(e error, depth int, path []int) bool {
+		r = append(r, walkItem{err: e, depth: depth, path: append([]int{}, path...)})
+		return true
+	})
+	return r
+}
+
+func Test_Walk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if r := walkAll(nil); len(r) != 0 {
+			t.Errorf("expected no calls, got %v", r)
+		}
+	})
+
+	t.Run("single error", func(t *testing.T) {
+		exp := []walkItem{{err: io.EOF, depth: 0, path: []int{}}}
+		if r := walkAll(io.EOF); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+	})
+
+	t.Run("single error chain", func(t *testing.T) {
+		e0 := io.EOF
+		e1 := Errorf("e1: %w", e0)
+		e2 := fmt.Errorf("e2: %w", e1)
+		exp := []walkItem{
+			{err: e2, depth: 0, path: []int{}},
+			{err: e1, depth: 1, path: []int{0}},
+			{err: e0, depth: 2, path: []int{0, 0}},
+		}
+		if r := walkAll(e2); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+	})
+
+	t.Run("exerr wrapping exerr", func(t *testing.T) {
+		e0 := io.EOF
+		e1 := Errorf("e1: %w", e0)
+		e2 := MarkPermanent(e1)
+		exp := []walkItem{
+			{err: e2, depth: 0, path: []int{}},
+			{err: e1, depth: 1, path: []int{0}},
+			{err: e0, depth: 2, path: []int{0, 0}},
+		}
+		if r := walkAll(e2); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+	})
+
+	t.Run("multi error chain", func(t *testing.T) {
+		eA := New("A")
+		eB := io.EOF
+		eC := fmt.Errorf("C: %w", io.ErrUnexpectedEOF)
+		join := errors.Join(eA, eB, eC)
+		root := AddField(join, "foo", 1)
+		exp := []walkItem{
+			{err: root, depth: 0, path: []int{}},
+			{err: eA, depth: 1, path: []int{0}},
+			{err: eB, depth: 1, path: []int{1}},
+			{err: eC, depth: 1, path: []int{2}},
+			{err: io.ErrUnexpectedEOF, depth: 2, path: []int{2, 0}},
+		}
+		if r := walkAll(root); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+	})
+
+	t.Run("exerr wrapping multiple errors", func(t *testing.T) {
+		err := Errorf("a: %w, b: %w", io.EOF, io.ErrClosedPipe)
+		exp := []walkItem{
+			{err: err, depth: 0, path: []int{}},
+			{err: io.EOF, depth: 1, path: []int{0}},
+			{err: io.ErrClosedPipe, depth: 1, path: []int{1}},
+		}
+		if r := walkAll(err); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+	})
+
+	t.Run("stop the walk", func(t *testing.T) {
+		err := errors.Join(fmt.Errorf("a: %w", io.EOF), io.ErrClosedPipe)
+		cnt := 0
+		Walk(err, func(e error, depth int, path []int) bool {
+			cnt++
+			return e != io.EOF
+		})
+		if cnt != 3 {
+			t.Errorf("expected fn to be called 3 times, got %d", cnt)
+		}
+	})
+}
+
+func Test_All(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		for d, e := range All(nil) {
+			t.Errorf("unexpected item %d %v", d, e)
+		}
+	})
+
+	t.Run("iterate over all", func(t *testing.T) {
+		inner := fmt.Errorf("inner: %w", io.EOF)
+		err := errors.Join(inner, io.ErrClosedPipe)
+		var depths []int
+		var errs []error
+		for d, e := range All(err) {
+			depths = append(depths, d)
+			errs = append(errs, e)
+		}
+		if exp := []int{0, 1, 2, 1}; !reflect.DeepEqual(depths, exp) {
+			t.Errorf("expected depths %v got %v", exp, depths)
+		}
+		if exp := []error{err, inner, io.EOF, io.ErrClosedPipe}; !reflect.DeepEqual(errs, exp) {
+			t.Errorf("expected errors %v got %v", exp, errs)
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		err := errors.Join(io.EOF, io.ErrClosedPipe)
+		cnt := 0
+		for _, e := range All(err) {
+			cnt++
+			if e == io.EOF {
+				break
+			}
+		}
+		if cnt != 2 {
+			t.Errorf("expected 2 iterations, got %d", cnt)
+		}
+	})
+}
+
+func Test_FieldsWithOrigin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil error", func(t *testing.T) {
+		if r := FieldsWithOrigin(nil); r != nil {
+			t.Errorf("expected nil, got %v", r)
+		}
+	})
+
+	t.Run("stdlib error", func(t *testing.T) {
+		if r := FieldsWithOrigin(io.EOF); r != nil {
+			t.Errorf("expected nil, got %v", r)
+		}
+	})
+
+	t.Run("fields in multiple layers", func(t *testing.T) {
+		eA := New("A").AddField("b", 2).AddField("a", 1)
+		eB := Errorf("B").AddField("a", 3)
+		root := AddField(fmt.Errorf("root: %w", errors.Join(eA, io.EOF, eB)), "c", 4)
+
+		exp := []FieldOrigin{
+			{Name: "c", Value: 4, Err: root, Depth: 0},
+			{Name: "a", Value: 1, Err: eA, Depth: 2},
+			{Name: "b", Value: 2, Err: eA, Depth: 2},
+			{Name: "a", Value: 3, Err: eB, Depth: 2},
+		}
+		if r := FieldsWithOrigin(root); !reflect.DeepEqual(r, exp) {
+			t.Errorf("expected\n%v\ngot\n%v", exp, r)
+		}
+
+		// other query funcs also see fields in the multi error chain
+		expectFieldValue(t, root, "b", 2)
+		if f := Fields(root); len(f) != 3 {
+			t.Errorf("expected 3 fields, got %v", f)
+		}
+	})
+}